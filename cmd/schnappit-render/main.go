@@ -0,0 +1,109 @@
+// Command schnappit-render draws a JSON or YAML annotation document onto its
+// base image without a GUI, for regenerating annotated images in CI. It
+// doesn't depend on Fyne, so it builds and runs on headless machines.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/owenrumney/schnappit/internal/output"
+	"github.com/owenrumney/schnappit/internal/render"
+)
+
+func main() {
+	log.SetFlags(0)
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		log.Fatal(err)
+	}
+}
+
+// run implements "schnappit-render doc.yaml -o out.png"
+func run(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("schnappit-render", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: schnappit-render [flags] <document.yaml|document.json>")
+		fs.PrintDefaults()
+	}
+
+	out := fs.String("o", "", "path to write the image to, as .png or .svg (default: the document's name with .png)")
+	base := fs.String("image", "", "base image to draw on, overriding the document's image")
+	optimize := fs.Bool("optimize", false, "make a PNG as small as possible, and report the size before and after")
+	quantize := fs.Bool("quantize", false, "with -optimize, reduce the PNG to a 256 colour palette if it has few colours")
+	downscale := fs.Bool("1x", false, "with -optimize, downscale the PNG to 1x using the document's scale factor")
+
+	// Allow flags after the document path as well as before it
+	var paths []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		paths = append(paths, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(paths) != 1 {
+		fs.Usage()
+		return errors.New("render needs exactly one document")
+	}
+
+	doc, err := render.Load(paths[0])
+	if err != nil {
+		return err
+	}
+	if *base != "" {
+		abs, err := filepath.Abs(*base)
+		if err != nil {
+			return err
+		}
+		doc.Image = abs
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(paths[0], filepath.Ext(paths[0])) + ".png"
+	}
+
+	img, err := doc.LoadImage()
+	if err != nil {
+		return err
+	}
+	rendered, annotations, err := render.Render(img, doc)
+	if err != nil {
+		return err
+	}
+
+	// SVG keeps the annotations as vectors over the base image
+	format, _ := output.FormatForPath(*out)
+	switch {
+	case strings.EqualFold(filepath.Ext(*out), ".svg"):
+		err = output.SaveSVGToPath(img, annotations, nil, *out)
+	case *optimize && (format == output.FormatPNG || format == ""):
+		opts := output.OptimizeOptions{Quantize: *quantize}
+		if *downscale {
+			opts.ScaleFactor = doc.ScaleFactor
+		}
+		var report output.OptimizeReport
+		if report, err = output.SaveOptimizedPNG(rendered, *out, opts); err == nil {
+			fmt.Fprintf(stdout, "Optimised %s: %s\n", *out, report)
+		}
+	default:
+		err = output.SaveToPath(rendered, *out)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Wrote %s with %d annotations\n", *out, len(annotations))
+	return nil
+}
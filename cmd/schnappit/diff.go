@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/owenrumney/schnappit/internal/diff"
+	"github.com/owenrumney/schnappit/internal/output"
+)
+
+// runDiff implements "schnappit diff before.png after.png -o out.png"
+func runDiff(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: schnappit diff [flags] <before> <after>")
+		fs.PrintDefaults()
+	}
+
+	opts := diff.DefaultOptions()
+	out := fs.String("o", "diff.png", "path to write the diff image to")
+	align := fs.String("align", "none", "how to line the images up: none, scale or offset")
+	offset := fs.String("offset", "", "position of the after image over the before image, as x,y")
+	fs.IntVar(&opts.MaxShift, "max-shift", diff.DefaultMaxShift, "furthest shift, in pixels, to search with -align offset")
+	threshold := fs.Uint("threshold", diff.DefaultThreshold, "per-channel difference, 0-255, to ignore as noise")
+
+	// Allow flags after the image paths as well as before them
+	var paths []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		paths = append(paths, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(paths) != 2 {
+		fs.Usage()
+		return errors.New("diff needs exactly two images")
+	}
+
+	switch *align {
+	case "none":
+		opts.Registration = diff.RegisterNone
+	case "scale":
+		opts.Registration = diff.RegisterScale
+	case "offset":
+		opts.Registration = diff.RegisterOffset
+	default:
+		return fmt.Errorf("unknown alignment %q: use none, scale or offset", *align)
+	}
+	if *offset != "" {
+		p, err := parsePoint(*offset)
+		if err != nil {
+			return fmt.Errorf("invalid offset: %w", err)
+		}
+		opts.Offset = p
+	}
+	if *threshold > 255 {
+		return fmt.Errorf("threshold must be between 0 and 255")
+	}
+	opts.Threshold = uint8(*threshold)
+
+	before, err := diff.Load(paths[0])
+	if err != nil {
+		return err
+	}
+	after, err := diff.Load(paths[1])
+	if err != nil {
+		return err
+	}
+
+	result := diff.Compare(before, after, opts)
+	if err := output.SaveToPath(result.Image, *out); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, result.Summary())
+	if result.Offset != (image.Point{}) {
+		fmt.Fprintf(stdout, "Aligned with offset %d,%d\n", result.Offset.X, result.Offset.Y)
+	}
+	for _, r := range result.Regions {
+		fmt.Fprintf(stdout, "  %dx%d at %d,%d\n", r.Dx(), r.Dy(), r.Min.X, r.Min.Y)
+	}
+	fmt.Fprintf(stdout, "Wrote %s\n", *out)
+	return nil
+}
+
+// parsePoint parses a point written as "x,y"
+func parsePoint(s string) (image.Point, error) {
+	xs, ys, ok := strings.Cut(s, ",")
+	if !ok {
+		return image.Point{}, fmt.Errorf("expected x,y but got %q", s)
+	}
+	x, err := strconv.Atoi(strings.TrimSpace(xs))
+	if err != nil {
+		return image.Point{}, err
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(ys))
+	if err != nil {
+		return image.Point{}, err
+	}
+	return image.Pt(x, y), nil
+}
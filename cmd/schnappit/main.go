@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 
@@ -10,6 +11,17 @@ import (
 func main() {
 	log.SetFlags(log.Ltime | log.Lshortfile)
 
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		if err := runDiff(os.Args[2:], os.Stdout); err != nil {
+			if err == flag.ErrHelp {
+				os.Exit(0)
+			}
+			log.SetFlags(0)
+			log.Fatal(err)
+		}
+		return
+	}
+
 	application := app.New()
 
 	if err := application.Run(); err != nil {
@@ -104,8 +104,10 @@ func (a *App) onCapture() {
 		return
 	}
 
-	// Detect which display contains the mouse cursor
+	// Detect which display contains the mouse cursor, and note the window
+	// being captured before the selector takes focus
 	displayIndex := capture.GetDisplayAtMousePosition()
+	appName, windowTitle := capture.FrontmostWindow()
 
 	log.Printf("Capturing display %d (where mouse cursor is located)...", displayIndex)
 	fullScreenshot, err := capture.CaptureDisplay(displayIndex)
@@ -122,7 +124,9 @@ func (a *App) onCapture() {
 
 	sel := selector.New(a.fyneApp, displayBounds, scaleFactor, fullScreenshot,
 		func(rect image.Rectangle) {
-			a.openEditorWithRegion(fullScreenshot, rect, scaleFactor)
+			a.openEditorWithRegion(fullScreenshot, rect, scaleFactor, func(ed *editor.Editor) {
+				ed.SetSource(displayIndex, appName, windowTitle)
+			})
 		},
 		func() {
 			a.capturing.Store(false)
@@ -132,8 +136,9 @@ func (a *App) onCapture() {
 	sel.Show()
 }
 
-// openEditorWithRegion crops the screenshot to the selected region and opens the editor
-func (a *App) openEditorWithRegion(fullScreenshot *image.RGBA, rect image.Rectangle, scaleFactor float64) {
+// openEditorWithRegion crops the screenshot to the selected region and opens
+// the editor, letting setup describe the capture before it is shown
+func (a *App) openEditorWithRegion(fullScreenshot *image.RGBA, rect image.Rectangle, scaleFactor float64, setup func(*editor.Editor)) {
 	defer func() { a.capturing.Store(false) }()
 
 	log.Printf("Opening editor with region: %v", rect)
@@ -157,5 +162,6 @@ func (a *App) openEditorWithRegion(fullScreenshot *image.RGBA, rect image.Rectan
 	draw.Draw(cropped, cropped.Bounds(), subImg, rect.Min, draw.Src)
 
 	ed := editor.New(a.fyneApp, cropped, scaleFactor)
+	setup(ed)
 	ed.Show()
 }
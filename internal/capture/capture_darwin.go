@@ -9,6 +9,7 @@ package capture
 #import <CoreGraphics/CoreGraphics.h>
 #import <ScreenCaptureKit/ScreenCaptureKit.h>
 #import <AppKit/AppKit.h>
+#include <stdlib.h>
 
 static int displayCount = 0;
 static CGDirectDisplayID *displays = NULL;
@@ -233,6 +234,42 @@ int SCK_CaptureRect(int displayIndex, int x, int y, int width, int height, void
     }
     return result;
 }
+
+// Get the name of the frontmost application and the title of its frontmost
+// window. Either may be left NULL; the caller frees both.
+void SCK_GetFrontmostWindow(char **app, char **title) {
+    *app = NULL;
+    *title = NULL;
+    @autoreleasepool {
+        NSRunningApplication *front = [[NSWorkspace sharedWorkspace] frontmostApplication];
+        if (front == nil) {
+            return;
+        }
+        if (front.localizedName != nil) {
+            *app = strdup([front.localizedName UTF8String]);
+        }
+
+        // Windows are listed front to back, so the first normal window
+        // owned by the application is its frontmost one
+        CFArrayRef windows = CGWindowListCopyWindowInfo(kCGWindowListOptionOnScreenOnly | kCGWindowListExcludeDesktopElements, kCGNullWindowID);
+        if (windows == NULL) {
+            return;
+        }
+        for (NSDictionary *info in (__bridge NSArray *)windows) {
+            NSNumber *owner = info[(__bridge NSString *)kCGWindowOwnerPID];
+            NSNumber *layer = info[(__bridge NSString *)kCGWindowLayer];
+            if ([owner intValue] != front.processIdentifier || [layer intValue] != 0) {
+                continue;
+            }
+            NSString *name = info[(__bridge NSString *)kCGWindowName];
+            if (name != nil && [name length] > 0) {
+                *title = strdup([name UTF8String]);
+                break;
+            }
+        }
+        CFRelease(windows);
+    }
+}
 */
 import "C"
 
@@ -273,6 +310,23 @@ func GetDisplayBounds(displayIndex int) image.Rectangle {
 	return image.Rect(int(x), int(y), int(x)+int(width), int(y)+int(height))
 }
 
+// FrontmostWindow returns the name of the frontmost application and the
+// title of its frontmost window, or empty strings if they aren't known. Call
+// it before showing any of our own windows.
+func FrontmostWindow() (app, title string) {
+	var cApp, cTitle *C.char
+	C.SCK_GetFrontmostWindow(&cApp, &cTitle)
+	if cApp != nil {
+		app = C.GoString(cApp)
+		C.free(unsafe.Pointer(cApp))
+	}
+	if cTitle != nil {
+		title = C.GoString(cTitle)
+		C.free(unsafe.Pointer(cTitle))
+	}
+	return app, title
+}
+
 // CaptureDisplay captures the entire display at the given index
 func CaptureDisplay(displayIndex int) (*image.RGBA, error) {
 	bounds := GetDisplayBounds(displayIndex)
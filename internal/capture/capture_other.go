@@ -28,6 +28,12 @@ func GetDisplayBounds(displayIndex int) image.Rectangle {
 	return image.Rectangle{}
 }
 
+// FrontmostWindow returns the name of the frontmost application and the
+// title of its frontmost window, which aren't known on this platform
+func FrontmostWindow() (app, title string) {
+	return "", ""
+}
+
 // CaptureDisplay captures the entire display at the given index
 func CaptureDisplay(displayIndex int) (*image.RGBA, error) {
 	return nil, fmt.Errorf("capture not implemented for %s", runtime.GOOS)
@@ -10,18 +10,166 @@ import (
 const (
 	configDir  = ".config/schnappit"
 	configFile = "config.json"
+
+	// maxRecentColors is how many recently used colours are remembered
+	maxRecentColors = 8
 )
 
 // Config represents the application configuration
 type Config struct {
 	Hotkey       string `json:"hotkey"`
 	StartOnLogin bool   `json:"start_on_login"`
+
+	// Annotation style, stored as hex colours and logical stroke widths
+	ToolColor    string   `json:"tool_color"`
+	StrokeWidth  int      `json:"stroke_width"`
+	CustomColors []string `json:"custom_colors,omitempty"`
+	RecentColors []string `json:"recent_colors,omitempty"`
+
+	// Options chosen from the tools' toolbar menus, by tool and option
+	// name, such as "spotlight" and "opacity". Tools fall back to their
+	// defaults for options that are missing or invalid.
+	ToolSettings map[string]map[string]any `json:"tool_settings,omitempty"`
+
+	// Format that images are saved in by default, such as "png", "jpeg",
+	// "gif", "bmp" or "tiff", and the JPEG quality from 1 to 100
+	ExportFormat string `json:"export_format"`
+	JPEGQuality  int    `json:"jpeg_quality"`
+
+	// Whether saved PNGs are made as small as possible, optionally by
+	// reducing them to a 256 colour palette and to 1x resolution
+	OptimizePNG  bool `json:"optimize_png"`
+	PNGQuantize  bool `json:"png_quantize"`
+	PNGDownscale bool `json:"png_downscale"`
+
+	// Whether saved PNGs also carry the original screenshot and
+	// annotations, so opening them restores the annotations for editing
+	EmbedProject bool `json:"embed_project"`
+
+	// Where captures are saved and how they are named, as templates with
+	// tokens such as {date}, {app} and {seq}. See output.Naming.
+	OutputDir        string `json:"output_dir"`
+	FilenameTemplate string `json:"filename_template"`
+
+	// What copying places on the clipboard: any of "image", "path",
+	// "file_uri", "markdown" and "html"
+	ClipboardPayloads []string `json:"clipboard_payloads"`
+
+	// Whether every copy or save also keeps a PNG copy in ArchiveDir, named
+	// with FilenameTemplate
+	KeepArchive bool   `json:"keep_archive"`
+	ArchiveDir  string `json:"archive_dir"`
+
+	// Where uploading sends the image. Uploading is disabled while the URL
+	// is empty.
+	Upload Upload `json:"upload"`
+
+	// Editor keyboard shortcuts, mapping action names to key combinations
+	// such as "r", "shift+d" or "mod+s". "mod" is Cmd on macOS and Ctrl
+	// elsewhere; several combinations can be separated by commas.
+	Shortcuts map[string]string `json:"shortcuts"`
+
+	// Frames that can be added around exported images, and the name of the
+	// one in use. An empty BeautifyPreset exports the image unframed.
+	BeautifyPresets []BeautifyPreset `json:"beautify_presets"`
+	BeautifyPreset  string           `json:"beautify_preset"`
+}
+
+// BeautifyPreset is a named frame for exported images. Sizes are logical
+// pixels, scaled to the capture when applied.
+type BeautifyPreset struct {
+	Name          string  `json:"name"`
+	Padding       int     `json:"padding"`
+	Background    string  `json:"background"`             // Hex colour, or empty for transparent
+	GradientEnd   string  `json:"gradient_end,omitempty"` // Hex colour the background fades to, if any
+	CornerRadius  int     `json:"corner_radius"`
+	ShadowBlur    int     `json:"shadow_blur"`
+	ShadowOpacity float64 `json:"shadow_opacity"`
+}
+
+// Upload describes an HTTP endpoint that images are uploaded to. Header and
+// field values can refer to environment variables as $NAME or ${NAME}, so
+// tokens needn't be written into the config file; $$ is a literal $. See
+// output.Uploader.
+type Upload struct {
+	URL         string            `json:"url"`
+	Method      string            `json:"method,omitempty"`       // POST if empty
+	Body        string            `json:"body,omitempty"`         // "multipart" (the default) or "raw"
+	Field       string            `json:"field,omitempty"`        // Multipart field for the image, "file" if empty
+	Fields      map[string]string `json:"fields,omitempty"`       // Extra multipart fields
+	Headers     map[string]string `json:"headers,omitempty"`      // Extra request headers
+	ResponseURL string            `json:"response_url,omitempty"` // JSONPath to the link, or the whole body if empty
+}
+
+// DefaultBeautifyPresets returns the frames available out of the box
+func DefaultBeautifyPresets() []BeautifyPreset {
+	return []BeautifyPreset{
+		{Name: "Light", Padding: 48, Background: "#f2f4f7", GradientEnd: "#dde3ec", CornerRadius: 10, ShadowBlur: 24, ShadowOpacity: 0.3},
+		{Name: "Dark", Padding: 48, Background: "#2b2f3a", GradientEnd: "#14161c", CornerRadius: 10, ShadowBlur: 24, ShadowOpacity: 0.6},
+		{Name: "Sunset", Padding: 64, Background: "#ff7e5f", GradientEnd: "#feb47b", CornerRadius: 12, ShadowBlur: 32, ShadowOpacity: 0.4},
+		{Name: "Plain", Padding: 24, Background: "#ffffff", CornerRadius: 0, ShadowBlur: 0},
+	}
+}
+
+// FindBeautifyPreset returns the preset with the given name, if there is one
+func (c *Config) FindBeautifyPreset(name string) (BeautifyPreset, bool) {
+	for _, p := range c.BeautifyPresets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return BeautifyPreset{}, false
+}
+
+// DefaultShortcuts returns the default editor keyboard shortcuts. Tools
+// aren't listed; each falls back to the shortcut it was registered with.
+func DefaultShortcuts() map[string]string {
+	return map[string]string{
+		"copy":           "mod+c",
+		"save":           "mod+s",
+		"upload":         "mod+u",
+		"close":          "escape",
+		"delete":         "backspace, delete",
+		"help":           "shift+slash, f1",
+		"zoom.in":        "mod+equal, mod+plus",
+		"zoom.out":       "mod+minus",
+		"zoom.fit":       "mod+0",
+		"zoom.actual":    "mod+1",
+		"layer.forward":  "mod+bracketright",
+		"layer.backward": "mod+bracketleft",
+		"layer.front":    "mod+shift+bracketright",
+		"layer.back":     "mod+shift+bracketleft",
+		"color.1":        "1",
+		"color.2":        "2",
+		"color.3":        "3",
+		"color.4":        "4",
+		"color.5":        "5",
+		"color.6":        "6",
+		"color.7":        "7",
+		"color.8":        "8",
+	}
 }
 
 // Default returns the default configuration
 func Default() *Config {
 	return &Config{
-		Hotkey: "cmd+shift+x",
+		Hotkey:      "cmd+shift+x",
+		ToolColor:   "#ff0000",
+		StrokeWidth: 3,
+
+		ExportFormat: "png",
+		JPEGQuality:  90,
+
+		OutputDir:        "~/Pictures/schnappit",
+		FilenameTemplate: "schnappit-{date}-{time}",
+
+		ClipboardPayloads: []string{"image"},
+
+		ArchiveDir: "~/Pictures/schnappit/archive",
+
+		Shortcuts: DefaultShortcuts(),
+
+		BeautifyPresets: DefaultBeautifyPresets(),
 	}
 }
 
@@ -42,16 +190,74 @@ func Load() (*Config, error) {
 		return Default(), nil
 	}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	// Start from the defaults so settings missing from older files keep
+	// sensible values. Shortcuts are merged key by key, so a file that
+	// rebinds one action keeps the defaults for the rest.
+	cfg := Default()
+	if err := json.Unmarshal(data, cfg); err != nil {
 		return Default(), nil
 	}
 
 	if cfg.Hotkey == "" {
 		cfg.Hotkey = Default().Hotkey
 	}
+	if cfg.ToolColor == "" {
+		cfg.ToolColor = Default().ToolColor
+	}
+	if cfg.StrokeWidth <= 0 {
+		cfg.StrokeWidth = Default().StrokeWidth
+	}
+	if cfg.Shortcuts == nil {
+		cfg.Shortcuts = DefaultShortcuts()
+	}
+	if cfg.ExportFormat == "" {
+		cfg.ExportFormat = Default().ExportFormat
+	}
+	if cfg.JPEGQuality < 1 || cfg.JPEGQuality > 100 {
+		cfg.JPEGQuality = Default().JPEGQuality
+	}
+	if cfg.OutputDir == "" {
+		cfg.OutputDir = Default().OutputDir
+	}
+	if cfg.FilenameTemplate == "" {
+		cfg.FilenameTemplate = Default().FilenameTemplate
+	}
+	if len(cfg.ClipboardPayloads) == 0 {
+		cfg.ClipboardPayloads = Default().ClipboardPayloads
+	}
+	if cfg.ArchiveDir == "" {
+		cfg.ArchiveDir = Default().ArchiveDir
+	}
+	if cfg.BeautifyPresets == nil {
+		cfg.BeautifyPresets = DefaultBeautifyPresets()
+	}
+	if _, ok := cfg.FindBeautifyPreset(cfg.BeautifyPreset); !ok {
+		cfg.BeautifyPreset = ""
+	}
+
+	return cfg, nil
+}
 
-	return &cfg, nil
+// AddRecentColor records a colour as the most recently used, dropping any
+// earlier use of it and the oldest entries beyond the limit
+func (c *Config) AddRecentColor(hex string) {
+	recent := []string{hex}
+	for _, existing := range c.RecentColors {
+		if existing != hex && len(recent) < maxRecentColors {
+			recent = append(recent, existing)
+		}
+	}
+	c.RecentColors = recent
+}
+
+// AddCustomColor saves a colour to the custom palette if it isn't already there
+func (c *Config) AddCustomColor(hex string) {
+	for _, existing := range c.CustomColors {
+		if existing == hex {
+			return
+		}
+	}
+	c.CustomColors = append(c.CustomColors, hex)
 }
 
 // Save writes the configuration to disk
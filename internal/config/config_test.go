@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -12,6 +13,12 @@ func TestDefault(t *testing.T) {
 	if cfg.Hotkey != "cmd+shift+x" {
 		t.Errorf("Default().Hotkey = %q, want %q", cfg.Hotkey, "cmd+shift+x")
 	}
+	if cfg.ToolColor != "#ff0000" {
+		t.Errorf("Default().ToolColor = %q, want %q", cfg.ToolColor, "#ff0000")
+	}
+	if cfg.StrokeWidth != 3 {
+		t.Errorf("Default().StrokeWidth = %d, want 3", cfg.StrokeWidth)
+	}
 }
 
 func TestLoadCreatesDefault(t *testing.T) {
@@ -85,3 +92,221 @@ func TestPath(t *testing.T) {
 		t.Errorf("Path() should return absolute path or start with ~, got %q", path)
 	}
 }
+
+func TestLoadFillsMissingStyle(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	configPath := filepath.Join(tmpDir, configDir, configFile)
+	os.MkdirAll(filepath.Dir(configPath), 0755)
+	os.WriteFile(configPath, []byte(`{"hotkey": "ctrl+alt+p"}`), 0644)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.ToolColor != Default().ToolColor {
+		t.Errorf("Load().ToolColor = %q, want default %q", cfg.ToolColor, Default().ToolColor)
+	}
+	if cfg.StrokeWidth != Default().StrokeWidth {
+		t.Errorf("Load().StrokeWidth = %d, want default %d", cfg.StrokeWidth, Default().StrokeWidth)
+	}
+	if cfg.OutputDir != Default().OutputDir || cfg.FilenameTemplate != Default().FilenameTemplate {
+		t.Errorf("Load() naming = %q/%q, want the defaults", cfg.OutputDir, cfg.FilenameTemplate)
+	}
+	if len(cfg.ClipboardPayloads) != 1 || cfg.ClipboardPayloads[0] != "image" {
+		t.Errorf("Load().ClipboardPayloads = %v, want [image]", cfg.ClipboardPayloads)
+	}
+	if cfg.KeepArchive || cfg.ArchiveDir != Default().ArchiveDir {
+		t.Errorf("Load() archive = %v/%q, want it off in %q", cfg.KeepArchive, cfg.ArchiveDir, Default().ArchiveDir)
+	}
+}
+
+func TestLoadRejectsOutOfRangeSettings(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	configPath := filepath.Join(tmpDir, configDir, configFile)
+	os.MkdirAll(filepath.Dir(configPath), 0755)
+	os.WriteFile(configPath, []byte(`{"jpeg_quality": 150}`), 0644)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.JPEGQuality != Default().JPEGQuality {
+		t.Errorf("Load().JPEGQuality = %v, want default %v", cfg.JPEGQuality, Default().JPEGQuality)
+	}
+}
+
+func TestLoadMergesShortcuts(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	configPath := filepath.Join(tmpDir, configDir, configFile)
+	os.MkdirAll(filepath.Dir(configPath), 0755)
+	os.WriteFile(configPath, []byte(`{"shortcuts": {"tool.arrow": "q", "custom": "x"}}`), 0644)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := cfg.Shortcuts["tool.arrow"]; got != "q" {
+		t.Errorf("Shortcuts[tool.arrow] = %q, want %q", got, "q")
+	}
+	if got, want := cfg.Shortcuts["copy"], DefaultShortcuts()["copy"]; got != want {
+		t.Errorf("Shortcuts[copy] = %q, want default %q", got, want)
+	}
+	if got := cfg.Shortcuts["custom"]; got != "x" {
+		t.Errorf("Shortcuts[custom] = %q, want %q", got, "x")
+	}
+}
+
+func TestSaveAndLoadStyle(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	cfg := Default()
+	cfg.ToolColor = "#00ff00"
+	cfg.StrokeWidth = 8
+	cfg.AddCustomColor("#123456")
+	cfg.AddRecentColor("#00ff00")
+	cfg.ToolSettings = map[string]map[string]any{"magnifier": {"zoom": 3.0, "connector": false}}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if loaded.ToolColor != "#00ff00" || loaded.StrokeWidth != 8 {
+		t.Errorf("Load() style = %q/%d, want #00ff00/8", loaded.ToolColor, loaded.StrokeWidth)
+	}
+	if len(loaded.CustomColors) != 1 || loaded.CustomColors[0] != "#123456" {
+		t.Errorf("Load().CustomColors = %v, want [#123456]", loaded.CustomColors)
+	}
+	if len(loaded.RecentColors) != 1 || loaded.RecentColors[0] != "#00ff00" {
+		t.Errorf("Load().RecentColors = %v, want [#00ff00]", loaded.RecentColors)
+	}
+	if m := loaded.ToolSettings["magnifier"]; m["zoom"] != 3.0 || m["connector"] != false {
+		t.Errorf("Load().ToolSettings = %v, want the magnifier's zoom and connector", loaded.ToolSettings)
+	}
+}
+
+func TestAddRecentColor(t *testing.T) {
+	cfg := Default()
+	for _, c := range []string{"#000001", "#000002", "#000003"} {
+		cfg.AddRecentColor(c)
+	}
+	cfg.AddRecentColor("#000001")
+
+	want := []string{"#000001", "#000003", "#000002"}
+	if len(cfg.RecentColors) != len(want) {
+		t.Fatalf("RecentColors = %v, want %v", cfg.RecentColors, want)
+	}
+	for i := range want {
+		if cfg.RecentColors[i] != want[i] {
+			t.Errorf("RecentColors[%d] = %q, want %q", i, cfg.RecentColors[i], want[i])
+		}
+	}
+
+	for i := 0; i < maxRecentColors+4; i++ {
+		cfg.AddRecentColor(fmt.Sprintf("#1000%02x", i))
+	}
+	if len(cfg.RecentColors) != maxRecentColors {
+		t.Errorf("RecentColors should be capped at %d, got %d", maxRecentColors, len(cfg.RecentColors))
+	}
+}
+
+func TestAddCustomColor(t *testing.T) {
+	cfg := Default()
+	cfg.AddCustomColor("#abcdef")
+	cfg.AddCustomColor("#abcdef")
+
+	if len(cfg.CustomColors) != 1 {
+		t.Errorf("CustomColors should not contain duplicates, got %v", cfg.CustomColors)
+	}
+}
+
+func TestLoadBeautifyPresets(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	configPath := filepath.Join(tmpDir, configDir, configFile)
+	os.MkdirAll(filepath.Dir(configPath), 0755)
+
+	tests := []struct {
+		name       string
+		data       string
+		wantCount  int
+		wantPreset string
+	}{
+		{"missing presets use defaults", `{"beautify_preset": "Dark"}`, len(DefaultBeautifyPresets()), "Dark"},
+		{"custom presets replace defaults", `{"beautify_presets": [{"name": "Slides", "padding": 80}], "beautify_preset": "Slides"}`, 1, "Slides"},
+		{"unknown preset is cleared", `{"beautify_preset": "Nope"}`, len(DefaultBeautifyPresets()), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.WriteFile(configPath, []byte(tt.data), 0644)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if len(cfg.BeautifyPresets) != tt.wantCount {
+				t.Errorf("len(BeautifyPresets) = %d, want %d", len(cfg.BeautifyPresets), tt.wantCount)
+			}
+			if cfg.BeautifyPreset != tt.wantPreset {
+				t.Errorf("BeautifyPreset = %q, want %q", cfg.BeautifyPreset, tt.wantPreset)
+			}
+		})
+	}
+}
+
+func TestLoadUpload(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	configPath := filepath.Join(tmpDir, configDir, configFile)
+	os.MkdirAll(filepath.Dir(configPath), 0755)
+	os.WriteFile(configPath, []byte(`{"upload": {
+		"url": "https://api.example.com/upload",
+		"field": "image",
+		"headers": {"Authorization": "Bearer ${TOKEN}"},
+		"response_url": "$.data.link"
+	}}`), 0644)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	up := cfg.Upload
+	if up.URL != "https://api.example.com/upload" || up.Field != "image" || up.ResponseURL != "$.data.link" {
+		t.Errorf("Load().Upload = %+v", up)
+	}
+	// Variables are kept as written, to be expanded when uploading
+	if up.Headers["Authorization"] != "Bearer ${TOKEN}" {
+		t.Errorf("Authorization header = %q", up.Headers["Authorization"])
+	}
+	if Default().Upload.URL != "" {
+		t.Error("uploading should be off by default")
+	}
+}
@@ -0,0 +1,305 @@
+// Package diff compares two screenshots and highlights what changed
+package diff
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // Register JPEG decoding for Load
+	_ "image/png"  // Register PNG decoding for Load
+	"math"
+	"os"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Registration is how the second image is lined up with the first
+type Registration int
+
+const (
+	// RegisterNone compares the images pixel for pixel from their top left
+	// corners, after applying Options.Offset
+	RegisterNone Registration = iota
+	// RegisterScale resizes the second image to the size of the first, for
+	// captures taken at different display scales
+	RegisterScale
+	// RegisterOffset searches for the shift of up to Options.MaxShift pixels
+	// that best lines the images up, for captures framed slightly differently
+	RegisterOffset
+)
+
+// DefaultThreshold is the per-channel difference, out of 255, below which
+// pixels are treated as unchanged, absorbing compression and rendering noise
+const DefaultThreshold = 16
+
+// DefaultMaxShift is how far RegisterOffset searches by default
+const DefaultMaxShift = 16
+
+// regionCell is the size of the grid changed pixels are grouped on when
+// finding changed regions; changes closer than this are merged
+const regionCell = 8
+
+// HighlightColor marks changed pixels in the diff image
+var HighlightColor = color.RGBA{R: 255, G: 0, B: 110, A: 255}
+
+// Options controls how two images are compared
+type Options struct {
+	Registration Registration
+	Offset       image.Point // Position of the second image's origin over the first
+	MaxShift     int         // Search distance for RegisterOffset
+	Threshold    uint8       // Per-channel tolerance before a pixel counts as changed
+}
+
+// DefaultOptions returns options that compare images as they are, tolerating
+// slight colour noise
+func DefaultOptions() Options {
+	return Options{MaxShift: DefaultMaxShift, Threshold: DefaultThreshold}
+}
+
+// Result is the outcome of comparing two images
+type Result struct {
+	// Image is the first image, faded, with changed pixels highlighted and
+	// changed regions outlined
+	Image *image.RGBA
+
+	ChangedPixels int
+	TotalPixels   int
+
+	// Regions bound each group of nearby changes, in the first image's
+	// coordinates
+	Regions []image.Rectangle
+
+	// Offset is where the second image was placed over the first
+	Offset image.Point
+}
+
+// ChangedPercent returns the proportion of pixels that changed, from 0 to 100
+func (r *Result) ChangedPercent() float64 {
+	if r.TotalPixels == 0 {
+		return 0
+	}
+	return float64(r.ChangedPixels) / float64(r.TotalPixels) * 100
+}
+
+// Summary describes the result in one line
+func (r *Result) Summary() string {
+	return fmt.Sprintf("%.2f%% of pixels changed (%d of %d) in %d regions",
+		r.ChangedPercent(), r.ChangedPixels, r.TotalPixels, len(r.Regions))
+}
+
+// Compare compares after against before. Pixels of before that after
+// doesn't cover, once registered, count as changed.
+func Compare(before, after image.Image, opts Options) *Result {
+	a := toRGBA(before)
+	b := toRGBA(after)
+
+	offset := opts.Offset
+	switch opts.Registration {
+	case RegisterScale:
+		if b.Bounds().Size() != a.Bounds().Size() {
+			scaled := image.NewRGBA(image.Rectangle{Max: a.Bounds().Size()})
+			xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), b, b.Bounds(), xdraw.Src, nil)
+			b = scaled
+		}
+	case RegisterOffset:
+		offset = bestOffset(a, b, max(0, opts.MaxShift))
+	}
+
+	changed := changedMask(a, b, offset, opts.Threshold)
+	result := &Result{
+		TotalPixels: a.Bounds().Dx() * a.Bounds().Dy(),
+		Offset:      offset,
+	}
+	for _, v := range changed.Pix {
+		if v != 0 {
+			result.ChangedPixels++
+		}
+	}
+	result.Regions = changedRegions(changed)
+	result.Image = render(a, changed, result.Regions)
+	return result
+}
+
+// Load reads a PNG or JPEG image from disk
+func Load(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// toRGBA returns img as a tightly packed RGBA image with its origin at 0, 0
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	if rgba, ok := img.(*image.RGBA); ok && b.Min == (image.Point{}) && rgba.Stride == 4*b.Dx() {
+		return rgba
+	}
+	rgba := image.NewRGBA(image.Rectangle{Max: b.Size()})
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+// pixelDiff returns the largest per-channel difference between two pixels
+// at the given offsets into a.Pix and b.Pix
+func pixelDiff(a, b []uint8, i, j int) uint8 {
+	var d uint8
+	for c := range 4 {
+		v := a[i+c] - b[j+c]
+		if a[i+c] < b[j+c] {
+			v = b[j+c] - a[i+c]
+		}
+		d = max(d, v)
+	}
+	return d
+}
+
+// changedMask marks each pixel of a that differs from b, with b's origin
+// placed at offset
+func changedMask(a, b *image.RGBA, offset image.Point, threshold uint8) *image.Alpha {
+	mask := image.NewAlpha(a.Bounds())
+	overlap := a.Bounds().Intersect(b.Bounds().Add(offset))
+
+	for y := range a.Rect.Dy() {
+		for x := range a.Rect.Dx() {
+			if !image.Pt(x, y).In(overlap) {
+				mask.Pix[y*mask.Stride+x] = 0xff
+				continue
+			}
+			i := a.PixOffset(x, y)
+			j := b.PixOffset(x-offset.X, y-offset.Y)
+			if pixelDiff(a.Pix, b.Pix, i, j) > threshold {
+				mask.Pix[y*mask.Stride+x] = 0xff
+			}
+		}
+	}
+	return mask
+}
+
+// bestOffset returns the shift of b, within maxShift pixels in each
+// direction, that makes it most closely match a. The comparison samples a
+// sparse grid to keep the search quick on large captures.
+func bestOffset(a, b *image.RGBA, maxShift int) image.Point {
+	const step = 4
+
+	best := image.Point{}
+	bestScore := math.Inf(1)
+	for dy := -maxShift; dy <= maxShift; dy++ {
+		for dx := -maxShift; dx <= maxShift; dx++ {
+			offset := image.Pt(dx, dy)
+			overlap := a.Bounds().Intersect(b.Bounds().Add(offset))
+			if overlap.Dx() < a.Rect.Dx()/2 || overlap.Dy() < a.Rect.Dy()/2 {
+				continue
+			}
+
+			var total, samples float64
+			for y := overlap.Min.Y; y < overlap.Max.Y; y += step {
+				for x := overlap.Min.X; x < overlap.Max.X; x += step {
+					total += float64(pixelDiff(a.Pix, b.Pix, a.PixOffset(x, y), b.PixOffset(x-dx, y-dy)))
+					samples++
+				}
+			}
+
+			// Prefer the smallest shift when scores tie, so identical
+			// images stay unshifted
+			score := total / samples
+			if score < bestScore || (score == bestScore && abs(dx)+abs(dy) < abs(best.X)+abs(best.Y)) {
+				best, bestScore = offset, score
+			}
+		}
+	}
+	return best
+}
+
+// changedRegions groups changed pixels that lie within a grid cell of each
+// other and returns the bounding box of each group
+func changedRegions(changed *image.Alpha) []image.Rectangle {
+	size := changed.Bounds().Size()
+	cols := (size.X + regionCell - 1) / regionCell
+	rows := (size.Y + regionCell - 1) / regionCell
+
+	// Bounds of the changed pixels within each cell
+	cells := make([]image.Rectangle, cols*rows)
+	for y := range size.Y {
+		for x := range size.X {
+			if changed.Pix[y*changed.Stride+x] == 0 {
+				continue
+			}
+			i := (y/regionCell)*cols + x/regionCell
+			cells[i] = cells[i].Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+
+	// Flood fill across neighbouring cells, including diagonals
+	var regions []image.Rectangle
+	visited := make([]bool, len(cells))
+	for start := range cells {
+		if visited[start] || cells[start].Empty() {
+			continue
+		}
+		region := image.Rectangle{}
+		stack := []int{start}
+		visited[start] = true
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			region = region.Union(cells[i])
+
+			cx, cy := i%cols, i/cols
+			for ny := max(0, cy-1); ny <= min(rows-1, cy+1); ny++ {
+				for nx := max(0, cx-1); nx <= min(cols-1, cx+1); nx++ {
+					n := ny*cols + nx
+					if !visited[n] && !cells[n].Empty() {
+						visited[n] = true
+						stack = append(stack, n)
+					}
+				}
+			}
+		}
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+// render fades a towards white in greyscale, paints the changed pixels in
+// the highlight colour and outlines each changed region
+func render(a *image.RGBA, changed *image.Alpha, regions []image.Rectangle) *image.RGBA {
+	out := image.NewRGBA(a.Bounds())
+	for i := 0; i < len(a.Pix); i += 4 {
+		if changed.Pix[i/4] != 0 {
+			copy(out.Pix[i:i+4], []uint8{HighlightColor.R, HighlightColor.G, HighlightColor.B, HighlightColor.A})
+			continue
+		}
+		grey := (299*uint32(a.Pix[i]) + 587*uint32(a.Pix[i+1]) + 114*uint32(a.Pix[i+2])) / 1000
+		faded := uint8(grey/4 + 191)
+		copy(out.Pix[i:i+4], []uint8{faded, faded, faded, 0xff})
+	}
+
+	outline := image.NewUniform(HighlightColor)
+	for _, r := range regions {
+		r = r.Inset(-2).Intersect(out.Bounds())
+		for _, edge := range []image.Rectangle{
+			image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1),
+			image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y),
+			image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y),
+			image.Rect(r.Max.X-1, r.Min.Y, r.Max.X, r.Max.Y),
+		} {
+			draw.Draw(out, edge, outline, image.Point{}, draw.Src)
+		}
+	}
+	return out
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
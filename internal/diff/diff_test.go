@@ -0,0 +1,166 @@
+package diff
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owenrumney/schnappit/internal/output"
+	"github.com/owenrumney/schnappit/internal/project"
+)
+
+// pattern returns a test image with enough detail for offsets to be found
+func pattern(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{R: uint8(x * 7), G: uint8(y * 5), B: uint8((x * y) % 251), A: 255})
+		}
+	}
+	return img
+}
+
+func TestCompareIdentical(t *testing.T) {
+	img := pattern(40, 30)
+	r := Compare(img, img, DefaultOptions())
+
+	if r.ChangedPixels != 0 || len(r.Regions) != 0 {
+		t.Errorf("Compare() of identical images = %d changed in %v, want none", r.ChangedPixels, r.Regions)
+	}
+	if r.TotalPixels != 1200 {
+		t.Errorf("TotalPixels = %d, want 1200", r.TotalPixels)
+	}
+}
+
+func TestCompareRegions(t *testing.T) {
+	before := pattern(100, 80)
+	after := pattern(100, 80)
+	white := image.NewUniform(color.White)
+	draw.Draw(after, image.Rect(10, 10, 20, 15), white, image.Point{}, draw.Src)
+	draw.Draw(after, image.Rect(60, 50, 70, 60), white, image.Point{}, draw.Src)
+	// Noise below the threshold is ignored
+	after.Set(90, 5, color.RGBA{R: before.RGBAAt(90, 5).R + 3, G: before.RGBAAt(90, 5).G, B: before.RGBAAt(90, 5).B, A: 255})
+
+	r := Compare(before, after, DefaultOptions())
+
+	if r.ChangedPixels != 150 {
+		t.Errorf("ChangedPixels = %d, want 150", r.ChangedPixels)
+	}
+	if got, want := r.ChangedPercent(), 150.0/8000*100; got != want {
+		t.Errorf("ChangedPercent() = %v, want %v", got, want)
+	}
+	want := []image.Rectangle{image.Rect(10, 10, 20, 15), image.Rect(60, 50, 70, 60)}
+	if len(r.Regions) != len(want) || r.Regions[0] != want[0] || r.Regions[1] != want[1] {
+		t.Errorf("Regions = %v, want %v", r.Regions, want)
+	}
+
+	if got := r.Image.RGBAAt(15, 12); got != HighlightColor {
+		t.Errorf("changed pixel = %v, want highlight %v", got, HighlightColor)
+	}
+	if got := r.Image.RGBAAt(40, 40); got.R < 191 || got.R != got.G || got.G != got.B {
+		t.Errorf("unchanged pixel = %v, want faded grey", got)
+	}
+}
+
+func TestCompareRegistration(t *testing.T) {
+	before := pattern(60, 40)
+
+	t.Run("offset", func(t *testing.T) {
+		// after shows the same content shifted 3 right and 2 down
+		after := image.NewRGBA(before.Bounds())
+		draw.Draw(after, after.Bounds(), before, image.Pt(-3, -2), draw.Src)
+
+		opts := DefaultOptions()
+		opts.Registration = RegisterOffset
+		r := Compare(before, after, opts)
+
+		if r.Offset != image.Pt(-3, -2) {
+			t.Errorf("Offset = %v, want (-3,-2)", r.Offset)
+		}
+		// Only the strips after doesn't cover are changed
+		if want := 60*40 - 57*38; r.ChangedPixels != want {
+			t.Errorf("ChangedPixels = %d, want %d", r.ChangedPixels, want)
+		}
+	})
+
+	t.Run("scale", func(t *testing.T) {
+		flat := image.NewRGBA(image.Rect(0, 0, 60, 40))
+		draw.Draw(flat, flat.Bounds(), image.NewUniform(color.RGBA{R: 40, G: 90, B: 200, A: 255}), image.Point{}, draw.Src)
+		retina := image.NewRGBA(image.Rect(0, 0, 120, 80))
+		draw.Draw(retina, retina.Bounds(), image.NewUniform(color.RGBA{R: 40, G: 90, B: 200, A: 255}), image.Point{}, draw.Src)
+
+		opts := DefaultOptions()
+		unscaled := Compare(flat, retina, opts)
+		opts.Registration = RegisterScale
+		scaled := Compare(flat, retina, opts)
+
+		if scaled.ChangedPixels != 0 {
+			t.Errorf("ChangedPixels with RegisterScale = %d, want 0", scaled.ChangedPixels)
+		}
+		if unscaled.ChangedPixels != 0 {
+			t.Errorf("ChangedPixels without scaling = %d, want 0 for the overlapping region", unscaled.ChangedPixels)
+		}
+	})
+
+	t.Run("smaller after", func(t *testing.T) {
+		after := image.NewRGBA(image.Rect(0, 0, 30, 40))
+		draw.Draw(after, after.Bounds(), before, image.Point{}, draw.Src)
+
+		r := Compare(before, after, DefaultOptions())
+		if r.ChangedPixels != 30*40 {
+			t.Errorf("ChangedPixels = %d, want %d for the uncovered half", r.ChangedPixels, 30*40)
+		}
+		if len(r.Regions) != 1 || r.Regions[0] != image.Rect(30, 0, 60, 40) {
+			t.Errorf("Regions = %v, want [(30,0)-(60,40)]", r.Regions)
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "img.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, pattern(8, 6)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	img, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if img.Bounds().Size() != image.Pt(8, 6) {
+		t.Errorf("Load() size = %v, want 8x6", img.Bounds().Size())
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.png")); err == nil {
+		t.Error("Load() of a missing file should fail")
+	}
+}
+
+func TestLoadEmbeddedProject(t *testing.T) {
+	// The exported pixels are compared, not the capture embedded with them
+	original := pattern(8, 6)
+	exported := pattern(16, 12)
+	path := filepath.Join(t.TempDir(), "editable.png")
+	err := output.SaveToPathWithOptions(exported, path, output.EncodeOptions{
+		Project: &project.Project{Screenshot: original},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if img.Bounds().Size() != image.Pt(16, 12) {
+		t.Errorf("Load() size = %v, want the exported 16x12", img.Bounds().Size())
+	}
+}
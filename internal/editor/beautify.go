@@ -0,0 +1,74 @@
+package editor
+
+import (
+	"image/color"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/owenrumney/schnappit/internal/config"
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+	"github.com/owenrumney/schnappit/internal/output"
+)
+
+// showBeautifyMenu pops up the frame presets below anchor. The chosen
+// preset is applied when copying or saving.
+func (e *Editor) showBeautifyMenu(anchor fyne.CanvasObject) {
+	choose := func(name string) func() {
+		return func() {
+			e.cfg.BeautifyPreset = name
+			e.saveConfig()
+		}
+	}
+
+	none := fyne.NewMenuItem("No Frame", choose(""))
+	none.Checked = e.cfg.BeautifyPreset == ""
+	items := []*fyne.MenuItem{none, fyne.NewMenuItemSeparator()}
+	for _, preset := range e.cfg.BeautifyPresets {
+		item := fyne.NewMenuItem(preset.Name, choose(preset.Name))
+		item.Checked = e.cfg.BeautifyPreset == preset.Name
+		items = append(items, item)
+	}
+
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(anchor)
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), e.window.Canvas(), pos.Add(fyne.NewPos(0, anchor.Size().Height)))
+}
+
+// frame returns the frame of the selected beautify preset, scaled to the
+// capture, or false if exports should be left unframed
+func (e *Editor) frame() (output.Frame, bool) {
+	preset, ok := e.cfg.FindBeautifyPreset(e.cfg.BeautifyPreset)
+	if !ok {
+		return output.Frame{}, false
+	}
+	return presetFrame(preset, e.scaleFactor), true
+}
+
+// presetFrame converts a config preset into a frame, scaling its logical
+// sizes to image pixels
+func presetFrame(p config.BeautifyPreset, scaleFactor float64) output.Frame {
+	scale := func(v int) int {
+		return int(float64(v) * scaleFactor)
+	}
+	parse := func(hex string) color.Color {
+		if hex == "" {
+			return nil
+		}
+		c, err := tools.ParseHexColor(hex)
+		if err != nil {
+			log.Printf("Invalid colour in beautify preset %q: %v", p.Name, err)
+			return nil
+		}
+		return c
+	}
+
+	return output.Frame{
+		Padding:       scale(p.Padding),
+		Background:    parse(p.Background),
+		GradientEnd:   parse(p.GradientEnd),
+		CornerRadius:  scale(p.CornerRadius),
+		ShadowBlur:    scale(p.ShadowBlur),
+		ShadowOpacity: p.ShadowOpacity,
+	}
+}
@@ -0,0 +1,71 @@
+package editor
+
+import (
+	"log"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/owenrumney/schnappit/internal/output"
+)
+
+// showClipboardMenu pops up the clipboard payload choices below anchor.
+// Each can be ticked or unticked before copying, and the choice is
+// remembered for the next capture.
+func (e *Editor) showClipboardMenu(anchor fyne.CanvasObject) {
+	selected := e.clipboardPayloads()
+
+	var items []*fyne.MenuItem
+	for _, p := range output.ClipboardPayloads() {
+		item := fyne.NewMenuItem(p.Name(), func() {
+			e.toggleClipboardPayload(p)
+		})
+		item.Checked = slices.Contains(selected, p)
+		items = append(items, item)
+	}
+	items = append(items, fyne.NewMenuItemSeparator(), fyne.NewMenuItem("Copy", e.copyToClipboard))
+
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(anchor)
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), e.window.Canvas(), pos.Add(fyne.NewPos(0, anchor.Size().Height)))
+}
+
+// toggleClipboardPayload adds or removes a payload from what copying
+// places on the clipboard, keeping at least one
+func (e *Editor) toggleClipboardPayload(p output.ClipboardPayload) {
+	selected := e.clipboardPayloads()
+	if i := slices.Index(selected, p); i >= 0 {
+		if len(selected) == 1 {
+			return
+		}
+		selected = slices.Delete(selected, i, i+1)
+	} else {
+		selected = append(selected, p)
+	}
+
+	e.cfg.ClipboardPayloads = e.cfg.ClipboardPayloads[:0]
+	for _, s := range selected {
+		e.cfg.ClipboardPayloads = append(e.cfg.ClipboardPayloads, string(s))
+	}
+	e.saveConfig()
+}
+
+// clipboardPayloads returns the configured clipboard payloads, skipping
+// any that aren't recognised and falling back to just the image
+func (e *Editor) clipboardPayloads() []output.ClipboardPayload {
+	var payloads []output.ClipboardPayload
+	for _, s := range e.cfg.ClipboardPayloads {
+		p, err := output.ParseClipboardPayload(s)
+		if err != nil {
+			log.Printf("Ignoring clipboard payload in config: %v", err)
+			continue
+		}
+		if !slices.Contains(payloads, p) {
+			payloads = append(payloads, p)
+		}
+	}
+	if len(payloads) == 0 {
+		return []output.ClipboardPayload{output.PayloadImage}
+	}
+	return payloads
+}
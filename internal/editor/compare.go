@@ -0,0 +1,105 @@
+package editor
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	nativedialog "github.com/sqweek/dialog"
+
+	"github.com/owenrumney/schnappit/internal/diff"
+	"github.com/owenrumney/schnappit/internal/project"
+)
+
+// compareAlignments are the registration choices offered when comparing
+var compareAlignments = []struct {
+	label        string
+	registration diff.Registration
+}{
+	{"As captured", diff.RegisterNone},
+	{"Match size", diff.RegisterScale},
+	{"Find offset", diff.RegisterOffset},
+}
+
+// compareWith asks for an earlier image and compares the screenshot against it
+func (e *Editor) compareWith() {
+	defaultDir := e.outputDir()
+
+	path, err := nativedialog.File().
+		Filter("Images", "png", "jpg", "jpeg").
+		SetStartDir(defaultDir).
+		Title("Compare With").
+		Load()
+	if err != nil {
+		if err == nativedialog.ErrCancelled {
+			return
+		}
+		dialog.ShowError(fmt.Errorf("failed to open image: %w", err), e.window)
+		return
+	}
+
+	before, err := diff.Load(path)
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	e.showCompareDialog(before)
+}
+
+// showCompareDialog asks how to line the images up, then shows the diff
+func (e *Editor) showCompareDialog(before image.Image) {
+	var labels []string
+	for _, a := range compareAlignments {
+		labels = append(labels, a.label)
+	}
+	align := widget.NewRadioGroup(labels, nil)
+	align.SetSelected(compareAlignments[0].label)
+	if before.Bounds().Size() != e.screenshot.Bounds().Size() {
+		align.SetSelected(compareAlignments[1].label)
+	}
+
+	dialog.ShowForm("Compare Screenshots", "Compare", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Alignment", align),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		opts := diff.DefaultOptions()
+		for _, a := range compareAlignments {
+			if a.label == align.Selected {
+				opts.Registration = a.registration
+			}
+		}
+		e.showDiffResult(diff.Compare(before, e.screenshot, opts))
+	}, e.window)
+}
+
+// showDiffResult previews a diff with its summary. The diff can then be
+// opened in the editor in place of the screenshot for annotating.
+func (e *Editor) showDiffResult(result *diff.Result) {
+	preview := canvas.NewImageFromImage(result.Image)
+	preview.FillMode = canvas.ImageFillContain
+	preview.SetMinSize(fyne.NewSize(480, 300))
+
+	summary := widget.NewLabel(result.Summary())
+	summary.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(nil, summary, nil, nil, preview)
+	dialog.ShowCustomConfirm("Comparison", "Annotate Diff", "Close", content, func(annotate bool) {
+		if !annotate {
+			return
+		}
+		e.loadProject(&project.Project{
+			Screenshot: result.Image,
+			Metadata: project.Metadata{
+				CapturedAt:  time.Now(),
+				ScaleFactor: e.scaleFactor,
+			},
+		})
+	}, e.window)
+}
@@ -5,6 +5,11 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -15,44 +20,88 @@ import (
 	"fyne.io/fyne/v2/widget"
 	nativedialog "github.com/sqweek/dialog"
 
+	"github.com/owenrumney/schnappit/internal/config"
 	"github.com/owenrumney/schnappit/internal/editor/tools"
 	"github.com/owenrumney/schnappit/internal/output"
+	"github.com/owenrumney/schnappit/internal/project"
 )
 
-// Tool represents the currently selected annotation tool
-type Tool int
-
-const (
-	ToolArrow Tool = iota
-	ToolRectangle
-)
+// handleSize is the logical size of the drag handles shown on adjustable annotations
+const handleSize = 8
 
 // Editor represents the screenshot annotation editor
 type Editor struct {
-	window      fyne.Window
-	screenshot  *image.RGBA
-	annotations []tools.Annotation
-	currentTool Tool
-	toolColor   color.Color
-	scaleFactor float64
+	window       fyne.Window
+	screenshot   *image.RGBA
+	annotations  []tools.Annotation
+	currentTool  *tools.Tool
+	toolColor    color.Color
+	strokeWidth  int // Logical stroke width, scaled by scaleFactor when drawing
+	scaleFactor  float64
+	capturedAt   time.Time
+	source       output.CaptureInfo // Display and window captured, for naming files
+	namingWarned bool               // The invalid naming in the config has been reported
+	cfg          *config.Config
 
 	// Drawing state
 	drawing      bool
 	startPoint   image.Point
 	currentPoint image.Point
-	imgCanvas    *canvas.Image
-	overlay      *image.RGBA // For compositing final image
-	preview      *image.RGBA // For live preview during drawing
+	pathPoints   []image.Point // Points collected while drawing with the pen
+	shiftDown    bool          // Constrains angles and proportions while held
+	spaceDown    bool          // Dragging pans the view while held
+	panning      bool
+	fillShapes   bool
+	eyedropper   bool // The next click samples a colour instead of drawing
+	cropping     bool // The next drag crops the image instead of drawing
+
+	// Handle dragging state for adjustable annotations
+	adjusting    tools.Adjustable
+	adjustHandle int
+
+	selected   tools.Annotation // Clicked annotation, acted on by Delete
+	keyActions map[keyBinding]func()
+
+	// View state: how the screenshot is scaled and positioned in the window
+	view             tools.Viewport
+	viewSize         fyne.Size
+	fitToWindow      bool // Refit the image whenever the window is resized
+	zoomModifierDown bool // Ctrl or Cmd is held, so scrolling zooms
+
+	imgCanvas   *canvas.Image
+	drawArea    *drawArea
+	zoomBtn     *widget.Button
+	layerList   *widget.List
+	layerPanel  *fyne.Container
+	colorSwatch *swatch
+	overlay     *image.RGBA // For compositing final image
+	preview     *image.RGBA // For live preview during drawing
 }
 
 // New creates a new editor with the given screenshot and scale factor
 func New(app fyne.App, screenshot *image.RGBA, scaleFactor float64) *Editor {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config, using defaults: %v", err)
+	}
+
+	toolColor, err := tools.ParseHexColor(cfg.ToolColor)
+	if err != nil {
+		log.Printf("Invalid tool colour in config, using default: %v", err)
+		toolColor, _ = tools.ParseHexColor(config.Default().ToolColor)
+	}
+
 	e := &Editor{
 		screenshot:  screenshot,
 		annotations: make([]tools.Annotation, 0),
-		currentTool: ToolArrow,
-		toolColor:   color.RGBA{R: 255, G: 0, B: 0, A: 255}, // Default red
+		currentTool: tools.Tools()[0],
+		toolColor:   toolColor,
+		strokeWidth: cfg.StrokeWidth,
 		scaleFactor: scaleFactor,
+		view:        tools.Viewport{Scale: 1 / scaleFactor},
+		fitToWindow: true,
+		capturedAt:  time.Now(),
+		cfg:         cfg,
 	}
 
 	e.window = app.NewWindow("Schnappit - Edit Screenshot")
@@ -61,6 +110,12 @@ func New(app fyne.App, screenshot *image.RGBA, scaleFactor float64) *Editor {
 	return e
 }
 
+// SetSource records which display and window the screenshot was taken
+// from, for the {display}, {app} and {title} file name tokens
+func (e *Editor) SetSource(display int, app, title string) {
+	e.source = output.CaptureInfo{Display: display, App: app, Title: title}
+}
+
 // setupUI creates the editor UI
 func (e *Editor) setupUI() {
 	e.overlay = image.NewRGBA(e.screenshot.Bounds())
@@ -70,40 +125,181 @@ func (e *Editor) setupUI() {
 	e.imgCanvas = canvas.NewImageFromImage(e.overlay)
 	e.imgCanvas.FillMode = canvas.ImageFillStretch
 
-	drawArea := newDrawArea(e)
+	e.drawArea = newDrawArea(e)
 
 	toolbar := e.createToolbar()
 
-	bounds := e.screenshot.Bounds()
-	logicalWidth := float32(float64(bounds.Dx()) / e.scaleFactor)
-	logicalHeight := float32(float64(bounds.Dy()) / e.scaleFactor)
+	content := container.NewBorder(toolbar, nil, nil, e.createLayerPanel(), container.NewClip(e.drawArea))
+	e.window.SetContent(content)
 
-	e.imgCanvas.SetMinSize(fyne.NewSize(logicalWidth, logicalHeight))
+	e.registerShortcuts(e.window.Canvas())
+	e.watchPinch()
 
-	imageContainer := container.NewStack(e.imgCanvas, drawArea)
-	content := container.NewBorder(toolbar, nil, nil, nil, imageContainer)
-	e.window.SetContent(content)
+	if deskCanvas, ok := e.window.Canvas().(desktop.Canvas); ok {
+		deskCanvas.SetOnKeyDown(func(ev *fyne.KeyEvent) {
+			e.setModifierKey(ev.Name, true)
+		})
+		deskCanvas.SetOnKeyUp(func(ev *fyne.KeyEvent) {
+			e.setModifierKey(ev.Name, false)
+		})
+	}
+
+	e.sizeToImage()
+}
+
+// setModifierKey tracks the held keys that change how the mouse behaves
+func (e *Editor) setModifierKey(key fyne.KeyName, down bool) {
+	switch key {
+	case desktop.KeyShiftLeft, desktop.KeyShiftRight:
+		e.shiftDown = down
+	case desktop.KeyControlLeft, desktop.KeyControlRight, desktop.KeySuperLeft, desktop.KeySuperRight:
+		e.zoomModifierDown = down
+	case fyne.KeySpace:
+		e.spaceDown = down
+	}
+}
+
+// sizeToImage sizes the window to the screenshot's logical size, capped so
+// that large captures stay on screen, and fits the image to it
+func (e *Editor) sizeToImage() {
+	bounds := e.screenshot.Bounds()
+	fit := tools.FitViewport(bounds.Size(),
+		float64(maxInitialWindow.Width), float64(maxInitialWindow.Height-toolbarHeight), e.nativeScale())
 
 	e.window.Resize(fyne.NewSize(
-		logicalWidth,
-		logicalHeight+50,
+		float32(float64(bounds.Dx())*fit.Scale),
+		float32(float64(bounds.Dy())*fit.Scale)+toolbarHeight,
 	))
+	e.zoomToFit()
 }
 
 // refreshOverlay redraws the overlay with the screenshot and all annotations
 func (e *Editor) refreshOverlay() {
 	draw.Draw(e.overlay, e.overlay.Bounds(), e.screenshot, image.Point{}, draw.Src)
-
-	for _, ann := range e.annotations {
-		ann.Draw(e.overlay)
-	}
+	tools.Render(e.overlay, e.annotations)
 }
 
 // updateCanvas refreshes the canvas display
 func (e *Editor) updateCanvas() {
 	e.refreshOverlay()
-	e.imgCanvas.Image = e.overlay
+
+	// Handles are drawn on the preview so they never end up in the exported image
+	draw.Draw(e.preview, e.preview.Bounds(), e.overlay, image.Point{}, draw.Src)
+	e.drawSelection(e.preview)
+	e.drawHandles(e.preview)
+	e.imgCanvas.Image = e.preview
 	e.imgCanvas.Refresh()
+	e.refreshLayers()
+}
+
+// drawHandles draws the drag handles of every adjustable annotation
+func (e *Editor) drawHandles(img *image.RGBA) {
+	size := e.imagePixels(handleSize)
+	border := max(1, e.imagePixels(1))
+	fill := image.NewUniform(color.White)
+	stroke := image.NewUniform(color.NRGBA{R: 0, G: 120, B: 215, A: 255})
+
+	for _, ann := range e.annotations {
+		adj, ok := ann.(tools.Adjustable)
+		if !ok || tools.IsHidden(ann) || tools.IsLocked(ann) {
+			continue
+		}
+		for _, h := range adj.Handles() {
+			r := image.Rect(h.X-size/2, h.Y-size/2, h.X+size/2, h.Y+size/2)
+			draw.Draw(img, r, stroke, image.Point{}, draw.Src)
+			draw.Draw(img, r.Inset(border), fill, image.Point{}, draw.Src)
+		}
+	}
+}
+
+// drawSelection outlines the selected annotation with a dashed box
+func (e *Editor) drawSelection(img *image.RGBA) {
+	if e.selected == nil || tools.IsHidden(e.selected) {
+		return
+	}
+
+	r := e.selected.Bounds().Inset(-e.imagePixels(2))
+	dash := max(1, e.imagePixels(4))
+	width := max(1, e.imagePixels(1))
+	stroke := image.NewUniform(color.NRGBA{R: 0, G: 120, B: 215, A: 255})
+
+	for x := r.Min.X; x < r.Max.X; x += 2 * dash {
+		end := min(x+dash, r.Max.X)
+		draw.Draw(img, image.Rect(x, r.Min.Y, end, r.Min.Y+width), stroke, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(x, r.Max.Y-width, end, r.Max.Y), stroke, image.Point{}, draw.Src)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y += 2 * dash {
+		end := min(y+dash, r.Max.Y)
+		draw.Draw(img, image.Rect(r.Min.X, y, r.Min.X+width, end), stroke, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(r.Max.X-width, y, r.Max.X, end), stroke, image.Point{}, draw.Src)
+	}
+}
+
+// deleteSelected removes the selected annotation
+func (e *Editor) deleteSelected() {
+	if e.selected == nil || tools.IsLocked(e.selected) {
+		return
+	}
+	e.annotations = slices.DeleteFunc(e.annotations, func(ann tools.Annotation) bool {
+		return ann == e.selected
+	})
+	e.selected = nil
+	e.updateCanvas()
+}
+
+// handleAt returns the topmost adjustable annotation with a handle at p
+func (e *Editor) handleAt(p image.Point) (tools.Adjustable, int) {
+	radius := e.imagePixels(handleSize)
+	for i := len(e.annotations) - 1; i >= 0; i-- {
+		adj, ok := e.annotations[i].(tools.Adjustable)
+		if !ok || tools.IsHidden(e.annotations[i]) || tools.IsLocked(e.annotations[i]) {
+			continue
+		}
+		for j, h := range adj.Handles() {
+			if abs(h.X-p.X) <= radius && abs(h.Y-p.Y) <= radius {
+				return adj, j
+			}
+		}
+	}
+	return nil, 0
+}
+
+// constrain applies the Shift modifier to the current drag point
+func (e *Editor) constrain(p image.Point) image.Point {
+	if !e.shiftDown {
+		return p
+	}
+
+	if e.cropping || e.currentTool.Constrain == nil {
+		return p
+	}
+	return e.currentTool.Constrain(e.startPoint, p)
+}
+
+// selectTool makes the named tool draw the next drag
+func (e *Editor) selectTool(name string) {
+	if tool := tools.LookupTool(name); tool != nil {
+		e.currentTool = tool
+		e.cropping = false
+	}
+}
+
+// drag returns the drag in progress
+func (e *Editor) drag() tools.Drag {
+	return tools.Drag{Start: e.startPoint, End: e.currentPoint, Path: e.pathPoints}
+}
+
+// toolContext returns the current style and the tool's settings
+func (e *Editor) toolContext(tool *tools.Tool) *tools.ToolContext {
+	return &tools.ToolContext{
+		Screenshot:  e.screenshot,
+		Annotations: e.annotations,
+		Color:       e.toolColor,
+		StrokeWidth: e.strokePixels(),
+		Filled:      e.fillShapes,
+		ScaleFactor: e.scaleFactor,
+		Settings:    e.toolSettings(tool),
+	}
 }
 
 // updatePreview refreshes the canvas with a preview of the current annotation being drawn
@@ -114,19 +310,10 @@ func (e *Editor) updatePreview() {
 
 	draw.Draw(e.preview, e.preview.Bounds(), e.overlay, image.Point{}, draw.Src)
 
-	strokeWidth := int(3 * e.scaleFactor)
-
-	switch e.currentTool {
-	case ToolArrow:
-		previewArrow := tools.NewArrow(e.startPoint, e.currentPoint, e.toolColor, strokeWidth)
-		previewArrow.Draw(e.preview)
-	case ToolRectangle:
-		rect := image.Rectangle{
-			Min: image.Pt(min(e.startPoint.X, e.currentPoint.X), min(e.startPoint.Y, e.currentPoint.Y)),
-			Max: image.Pt(max(e.startPoint.X, e.currentPoint.X), max(e.startPoint.Y, e.currentPoint.Y)),
-		}
-		previewRect := tools.NewRect(rect, e.toolColor, strokeWidth, false)
-		previewRect.Draw(e.preview)
+	if e.cropping {
+		e.drawCropPreview(e.drag().Rect())
+	} else {
+		e.currentTool.DrawPreview(e.preview, e.toolContext(e.currentTool), e.drag())
 	}
 
 	e.imgCanvas.Image = e.preview
@@ -146,21 +333,52 @@ func newDrawArea(e *Editor) *drawArea {
 }
 
 func (d *drawArea) CreateRenderer() fyne.WidgetRenderer {
-	return &drawAreaRenderer{}
+	return &drawAreaRenderer{area: d}
 }
 
-func (d *drawArea) Tapped(ev *fyne.PointEvent) {}
+// Tapped selects the annotation under a click that didn't drag
+func (d *drawArea) Tapped(ev *fyne.PointEvent) {
+	d.editor.adjusting = nil
+	d.editor.panning = false
+	if !d.editor.drawing {
+		return
+	}
+	d.editor.drawing = false
+	d.editor.pathPoints = nil
+
+	p := d.editor.startPoint
+	d.editor.selected = tools.AnnotationAt(d.editor.annotations, p.X, p.Y)
+	d.editor.updateCanvas()
+}
 
 func (d *drawArea) TappedSecondary(ev *fyne.PointEvent) {}
 
 func (d *drawArea) MouseDown(ev *desktop.MouseEvent) {
+	if d.editor.spaceDown {
+		d.editor.panning = true
+		return
+	}
+
+	d.editor.startPoint = d.editor.toImage(ev.Position)
+	d.editor.shiftDown = ev.Modifier&fyne.KeyModifierShift != 0
+
+	if d.editor.eyedropper {
+		d.editor.eyedropper = false
+		if d.editor.startPoint.In(d.editor.screenshot.Bounds()) {
+			d.editor.setColor(d.editor.screenshot.At(d.editor.startPoint.X, d.editor.startPoint.Y))
+		}
+		return
+	}
+
+	if adj, handle := d.editor.handleAt(d.editor.startPoint); adj != nil {
+		d.editor.adjusting = adj
+		d.editor.adjustHandle = handle
+		return
+	}
+
 	d.editor.drawing = true
-	scale := d.editor.scaleFactor
-	d.editor.startPoint = image.Pt(
-		int(float64(ev.Position.X)*scale),
-		int(float64(ev.Position.Y)*scale),
-	)
 	d.editor.currentPoint = d.editor.startPoint
+	d.editor.pathPoints = []image.Point{d.editor.startPoint}
 }
 
 func (d *drawArea) MouseUp(ev *desktop.MouseEvent) {
@@ -169,89 +387,183 @@ func (d *drawArea) MouseUp(ev *desktop.MouseEvent) {
 
 // Dragged implements fyne.Draggable for live preview
 func (d *drawArea) Dragged(ev *fyne.DragEvent) {
+	if d.editor.panning {
+		d.editor.pan(float64(ev.Dragged.DX), float64(ev.Dragged.DY))
+		return
+	}
+
+	point := d.editor.toImage(ev.Position)
+
+	if d.editor.adjusting != nil {
+		d.editor.adjusting.MoveHandle(d.editor.adjustHandle, point)
+		d.editor.updateCanvas()
+		return
+	}
+
 	if !d.editor.drawing {
 		return
 	}
 
-	scale := d.editor.scaleFactor
-	d.editor.currentPoint = image.Pt(
-		int(float64(ev.Position.X)*scale),
-		int(float64(ev.Position.Y)*scale),
-	)
+	d.editor.currentPoint = d.editor.constrain(point)
+	d.editor.pathPoints = append(d.editor.pathPoints, d.editor.currentPoint)
 
 	d.editor.updatePreview()
 }
 
 // DragEnd implements fyne.Draggable - finalizes the annotation
 func (d *drawArea) DragEnd() {
+	if d.editor.panning {
+		d.editor.panning = false
+		return
+	}
+
+	if d.editor.adjusting != nil {
+		d.editor.adjusting = nil
+		return
+	}
+
 	if !d.editor.drawing {
 		return
 	}
 	d.editor.drawing = false
 
-	scale := d.editor.scaleFactor
-	strokeWidth := int(3 * scale)
-
-	var ann tools.Annotation
-	switch d.editor.currentTool {
-	case ToolArrow:
-		ann = tools.NewArrow(d.editor.startPoint, d.editor.currentPoint, d.editor.toolColor, strokeWidth)
-	case ToolRectangle:
-		rect := image.Rectangle{
-			Min: image.Pt(min(d.editor.startPoint.X, d.editor.currentPoint.X), min(d.editor.startPoint.Y, d.editor.currentPoint.Y)),
-			Max: image.Pt(max(d.editor.startPoint.X, d.editor.currentPoint.X), max(d.editor.startPoint.Y, d.editor.currentPoint.Y)),
-		}
-		ann = tools.NewRect(rect, d.editor.toolColor, strokeWidth, false)
+	drag := d.editor.drag()
+	d.editor.pathPoints = nil
+
+	if d.editor.cropping {
+		d.editor.cropping = false
+		d.editor.cropTo(drag.Rect())
+		return
 	}
 
-	if ann != nil {
+	if ann := d.editor.currentTool.Complete(d.editor.toolContext(d.editor.currentTool), drag); ann != nil {
 		d.editor.annotations = append(d.editor.annotations, ann)
-		d.editor.updateCanvas()
 	}
+	d.editor.updateCanvas()
 }
 
-type drawAreaRenderer struct{}
+// drawAreaRenderer shows the screenshot at the editor's current zoom and pan
+type drawAreaRenderer struct {
+	area *drawArea
+}
 
-func (r *drawAreaRenderer) Destroy()                     {}
-func (r *drawAreaRenderer) Layout(size fyne.Size)        {}
-func (r *drawAreaRenderer) MinSize() fyne.Size           { return fyne.NewSize(0, 0) }
-func (r *drawAreaRenderer) Objects() []fyne.CanvasObject { return nil }
-func (r *drawAreaRenderer) Refresh()                     {}
+func (r *drawAreaRenderer) Destroy()              {}
+func (r *drawAreaRenderer) Layout(size fyne.Size) { r.area.editor.layoutImage(size) }
+func (r *drawAreaRenderer) MinSize() fyne.Size    { return fyne.NewSize(0, 0) }
+func (r *drawAreaRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.area.editor.imgCanvas}
+}
+func (r *drawAreaRenderer) Refresh() { r.area.editor.refreshView() }
 
 // createToolbar creates the annotation toolbar with icons
 func (e *Editor) createToolbar() *fyne.Container {
-	arrowBtn := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
-		e.currentTool = ToolArrow
+	var buttons []fyne.CanvasObject
+	for _, tool := range tools.Tools() {
+		var btn *widget.Button
+		btn = widget.NewButtonWithIcon("", theme.Icon(fyne.ThemeIconName(tool.Icon)), func() {
+			if len(tool.Options) > 0 {
+				e.showToolMenu(tool, btn)
+				return
+			}
+			e.selectTool(tool.Name)
+		})
+		btn.Importance = widget.MediumImportance
+		buttons = append(buttons, btn)
+	}
+
+	fillCheck := widget.NewCheck("Fill", func(checked bool) {
+		e.fillShapes = checked
+	})
+
+	e.colorSwatch = newSwatch(e.toolColor, e.showPalette)
+
+	eyedropperBtn := widget.NewButtonWithIcon("", theme.ColorChromaticIcon(), func() {
+		e.eyedropper = true
 	})
-	arrowBtn.Importance = widget.MediumImportance
+	eyedropperBtn.Importance = widget.MediumImportance
+
+	strokeSelect := e.createStrokeSelect()
 
-	rectBtn := widget.NewButtonWithIcon("", theme.CheckButtonIcon(), func() {
-		e.currentTool = ToolRectangle
+	var imageBtn *widget.Button
+	imageBtn = widget.NewButtonWithIcon("", theme.MediaPhotoIcon(), func() {
+		e.showImageMenu(imageBtn)
 	})
-	rectBtn.Importance = widget.MediumImportance
+	imageBtn.Importance = widget.MediumImportance
+
+	var projectBtn *widget.Button
+	projectBtn = widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		e.showProjectMenu(projectBtn)
+	})
+	projectBtn.Importance = widget.MediumImportance
 
 	copyBtn := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
 		e.copyToClipboard()
 	})
 	copyBtn.Importance = widget.HighImportance
 
+	var copyMenuBtn *widget.Button
+	copyMenuBtn = widget.NewButtonWithIcon("", theme.MenuDropDownIcon(), func() {
+		e.showClipboardMenu(copyMenuBtn)
+	})
+
 	saveBtn := widget.NewButtonWithIcon("", theme.DocumentSaveIcon(), func() {
 		e.saveToFile()
 	})
 	saveBtn.Importance = widget.HighImportance
 
+	var saveMenuBtn *widget.Button
+	saveMenuBtn = widget.NewButtonWithIcon("", theme.MenuDropDownIcon(), func() {
+		e.showSaveMenu(saveMenuBtn)
+	})
+
+	uploadBtn := widget.NewButtonWithIcon("", theme.UploadIcon(), func() {
+		e.upload()
+	})
+
+	var beautifyBtn *widget.Button
+	beautifyBtn = widget.NewButtonWithIcon("", theme.ViewFullScreenIcon(), func() {
+		e.showBeautifyMenu(beautifyBtn)
+	})
+
+	layersBtn := widget.NewButtonWithIcon("", theme.ListIcon(), func() {
+		e.toggleLayerPanel()
+	})
+
+	var zoomBtn *widget.Button
+	zoomBtn = widget.NewButtonWithIcon("100%", theme.ZoomFitIcon(), func() {
+		e.showZoomMenu(zoomBtn)
+	})
+	e.zoomBtn = zoomBtn
+
+	helpBtn := widget.NewButtonWithIcon("", theme.HelpIcon(), func() {
+		e.showShortcutHelp()
+	})
+
 	closeBtn := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
 		e.window.Close()
 	})
 
-	return container.NewHBox(
-		arrowBtn,
-		rectBtn,
+	return container.NewHBox(append(buttons,
+		fillCheck,
+		widget.NewSeparator(),
+		e.colorSwatch,
+		eyedropperBtn,
+		strokeSelect,
 		widget.NewSeparator(),
+		imageBtn,
+		zoomBtn,
+		layersBtn,
+		widget.NewSeparator(),
+		projectBtn,
+		beautifyBtn,
 		copyBtn,
+		copyMenuBtn,
 		saveBtn,
+		saveMenuBtn,
+		uploadBtn,
+		helpBtn,
 		closeBtn,
-	)
+	)...)
 }
 
 // Show displays the editor window
@@ -259,13 +571,27 @@ func (e *Editor) Show() {
 	e.window.Show()
 }
 
-// copyToClipboard copies the annotated screenshot to clipboard
+// copyToClipboard copies the annotated screenshot to clipboard, in each of
+// the selected representations. Those that refer to a file save a copy to
+// the output directory first.
 func (e *Editor) copyToClipboard() {
 	finalImg := e.renderFinal()
-	if err := output.CopyToClipboard(finalImg); err != nil {
+
+	payloads := e.clipboardPayloads()
+	var path string
+	if slices.ContainsFunc(payloads, output.ClipboardPayload.NeedsFile) {
+		var err error
+		if path, err = output.Archive(finalImg, e.naming(), e.captureInfo()); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to save a copy for the clipboard: %w", err), e.window)
+			return
+		}
+	}
+
+	if err := output.CopyPayloads(finalImg, path, e.scaleFactor, payloads); err != nil {
 		dialog.ShowError(fmt.Errorf("failed to copy to clipboard: %w", err), e.window)
 		return
 	}
+	e.archive(finalImg)
 	e.window.Close()
 }
 
@@ -273,11 +599,18 @@ func (e *Editor) copyToClipboard() {
 func (e *Editor) saveToFile() {
 	finalImg := e.renderFinal()
 
-	defaultDir, _ := output.GetOutputDir()
-	defaultFile := output.GenerateFilename()
+	format := e.exportFormat()
+	defaultDir, defaultFile := e.defaultSavePath(format.Extension())
 
-	path, err := nativedialog.File().
-		Filter("PNG Image", "png").
+	// The default format is listed first so the dialog starts with it
+	dlg := nativedialog.File().Filter(format.Name(), format.Extensions()...)
+	for _, f := range output.Formats() {
+		if f != format {
+			dlg = dlg.Filter(f.Name(), f.Extensions()...)
+		}
+	}
+	path, err := dlg.
+		Filter("SVG Image", "svg").
 		SetStartDir(defaultDir).
 		SetStartFile(defaultFile).
 		Title("Save Screenshot").
@@ -290,15 +623,186 @@ func (e *Editor) saveToFile() {
 		return
 	}
 
-	if err := output.SaveToPath(finalImg, path); err != nil {
+	// SVG keeps the annotations as vectors over the original screenshot,
+	// in the same frame; other formats are chosen by extension, using the
+	// default if there is none
+	ext := filepath.Ext(path)
+	switch f, ok := output.FormatForPath(path); {
+	case strings.EqualFold(ext, ".svg"):
+		var frame *output.Frame
+		if f, ok := e.frame(); ok {
+			frame = &f
+		}
+		e.finishSave(finalImg, output.SaveSVGToPath(e.screenshot, e.annotations, frame, path))
+	case ok:
+		e.saveImage(finalImg, path, f)
+	case ext == "":
+		e.saveImage(finalImg, path+format.Extension(), format)
+	default:
+		e.finishSave(finalImg, fmt.Errorf("unsupported image format %q", ext))
+	}
+}
+
+// finishSave closes the editor once an export has been saved, keeping an
+// archive copy, or reports why it couldn't be saved
+func (e *Editor) finishSave(img image.Image, err error) {
+	if err != nil {
 		dialog.ShowError(fmt.Errorf("failed to save file: %w", err), e.window)
 		return
 	}
+	e.archive(img)
 	e.window.Close()
 }
 
-// renderFinal renders the screenshot with all annotations
+// saveImage saves an exported image in the given format, then finishes the
+// save. PNGs carry the project if configured, so they can be re-edited, and
+// are optimised if configured, with a notification saying how much smaller
+// they became, since the editor closes straight afterwards.
+func (e *Editor) saveImage(img image.Image, path string, format output.Format) {
+	var embedded *project.Project
+	if e.cfg.EmbedProject {
+		embedded = e.currentProject()
+	}
+	if format != output.FormatPNG || !e.cfg.OptimizePNG {
+		e.finishSave(img, output.SaveToPathWithOptions(img, path, output.EncodeOptions{Format: format, Quality: e.cfg.JPEGQuality, Project: embedded}))
+		return
+	}
+
+	opts := output.OptimizeOptions{Quantize: e.cfg.PNGQuantize, Project: embedded}
+	if e.cfg.PNGDownscale {
+		opts.ScaleFactor = e.scaleFactor
+	}
+
+	// Trying each encoding takes seconds for large captures, so it runs in
+	// the background on copies, as the editor redraws while open
+	img = cloneImage(img)
+	if embedded != nil {
+		embedded.Screenshot = cloneImage(embedded.Screenshot)
+		embedded.Annotations = slices.Clone(embedded.Annotations)
+	}
+	progress := dialog.NewCustomWithoutButtons("Optimising…", widget.NewProgressBarInfinite(), e.window)
+	progress.Show()
+
+	go func() {
+		report, err := output.SaveOptimizedPNG(img, path, opts)
+		fyne.Do(func() {
+			progress.Hide()
+			if err == nil {
+				log.Printf("Optimised %s: %s", path, report)
+				fyne.CurrentApp().SendNotification(fyne.NewNotification("Schnappit", fmt.Sprintf("Saved %s: %s", filepath.Base(path), report)))
+			}
+			e.finishSave(img, err)
+		})
+	}()
+}
+
+// archive keeps a copy of an exported image in the archive directory, if
+// enabled. It saves in the background so the editor can close straight
+// away, and reports failures with a notification.
+func (e *Editor) archive(img image.Image) {
+	if !e.cfg.KeepArchive {
+		return
+	}
+	naming := output.Naming{Dir: e.cfg.ArchiveDir, Filename: e.naming().Filename}
+	info := e.captureInfo()
+	img = cloneImage(img)
+	go func() {
+		path, err := output.Archive(img, naming, info)
+		if err != nil {
+			log.Printf("Failed to archive capture: %v", err)
+			fyne.CurrentApp().SendNotification(fyne.NewNotification("Schnappit", fmt.Sprintf("Failed to keep a copy of the capture: %v", err)))
+			return
+		}
+		log.Printf("Archived capture to %s", path)
+	}()
+}
+
+// showSaveMenu pops up the save options below anchor
+func (e *Editor) showSaveMenu(anchor fyne.CanvasObject) {
+	keep := fyne.NewMenuItem("Always Keep a Copy", func() {
+		e.cfg.KeepArchive = !e.cfg.KeepArchive
+		e.saveConfig()
+	})
+	keep.Checked = e.cfg.KeepArchive
+
+	menu := fyne.NewMenu("", keep, fyne.NewMenuItemSeparator(), fyne.NewMenuItem("Save…", e.saveToFile))
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(anchor)
+	widget.ShowPopUpMenuAtPosition(menu, e.window.Canvas(), pos.Add(fyne.NewPos(0, anchor.Size().Height)))
+}
+
+// exportFormat returns the configured default format for saved images
+func (e *Editor) exportFormat() output.Format {
+	f, err := output.ParseFormat(e.cfg.ExportFormat)
+	if err != nil {
+		log.Printf("Invalid export format in config, using PNG: %v", err)
+		return output.FormatPNG
+	}
+	return f
+}
+
+// naming returns the configured output directory and file name templates,
+// or the defaults if they are invalid, saying so once per capture
+func (e *Editor) naming() output.Naming {
+	n := output.Naming{Dir: e.cfg.OutputDir, Filename: e.cfg.FilenameTemplate}
+	if err := n.Validate(); err != nil {
+		log.Printf("Invalid output naming in config, using defaults: %v", err)
+		if !e.namingWarned {
+			e.namingWarned = true
+			fyne.CurrentApp().SendNotification(fyne.NewNotification("Schnappit", fmt.Sprintf("Saving with the default names: %v", err)))
+		}
+		return output.DefaultNaming()
+	}
+	return n
+}
+
+// captureInfo describes the screenshot for naming files
+func (e *Editor) captureInfo() output.CaptureInfo {
+	info := e.source
+	info.Time = e.capturedAt
+	return info
+}
+
+// outputDir returns the directory file dialogs start in
+func (e *Editor) outputDir() string {
+	dir, err := e.naming().OutputDir(e.captureInfo())
+	if err != nil {
+		log.Printf("Failed to create output directory: %v", err)
+	}
+	return dir
+}
+
+// defaultSavePath returns the directory and file name save dialogs start
+// with, named from the templates with the given extension
+func (e *Editor) defaultSavePath(ext string) (dir, file string) {
+	path, err := e.naming().Path(e.captureInfo(), ext)
+	if err != nil {
+		log.Printf("Failed to name file: %v", err)
+		return e.outputDir(), ""
+	}
+	return filepath.Split(path)
+}
+
+// renderFinal renders the screenshot with all annotations, framed by the
+// selected beautify preset
 func (e *Editor) renderFinal() image.Image {
 	e.refreshOverlay()
+	if frame, ok := e.frame(); ok {
+		return output.Beautify(e.overlay, frame)
+	}
 	return e.overlay
 }
+
+// cloneImage copies an image, so it can be used in the background while
+// the editor goes on redrawing its overlay
+func cloneImage(img image.Image) *image.RGBA {
+	clone := image.NewRGBA(img.Bounds())
+	draw.Draw(clone, clone.Bounds(), img, img.Bounds().Min, draw.Src)
+	return clone
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
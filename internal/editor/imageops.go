@@ -0,0 +1,119 @@
+package editor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+)
+
+// minCropSize is the smallest crop, in pixels, that will be applied; anything
+// smaller is treated as an accidental click
+const minCropSize = 4
+
+// cropDimColor darkens the area that will be removed by a crop
+var cropDimColor = color.NRGBA{R: 0, G: 0, B: 0, A: 140}
+
+// showImageMenu pops up the image operations menu below anchor
+func (e *Editor) showImageMenu(anchor fyne.CanvasObject) {
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("Crop", func() {
+			e.cropping = true
+		}),
+		fyne.NewMenuItem("Resize…", e.showResizeDialog),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Rotate Left", func() {
+			e.applyTransform(tools.Rotate90(e.screenshot, false))
+		}),
+		fyne.NewMenuItem("Rotate Right", func() {
+			e.applyTransform(tools.Rotate90(e.screenshot, true))
+		}),
+		fyne.NewMenuItem("Flip Horizontal", func() {
+			e.applyTransform(tools.Flip(e.screenshot, true))
+		}),
+		fyne.NewMenuItem("Flip Vertical", func() {
+			e.applyTransform(tools.Flip(e.screenshot, false))
+		}),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Compare With…", e.compareWith),
+	)
+
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(anchor)
+	widget.ShowPopUpMenuAtPosition(menu, e.window.Canvas(), pos.Add(fyne.NewPos(0, anchor.Size().Height)))
+}
+
+// drawCropPreview dims everything outside the proposed crop
+func (e *Editor) drawCropPreview(rect image.Rectangle) {
+	dim := image.NewUniform(cropDimColor)
+	b := e.preview.Bounds()
+	for _, r := range []image.Rectangle{
+		image.Rect(b.Min.X, b.Min.Y, b.Max.X, rect.Min.Y),
+		image.Rect(b.Min.X, rect.Max.Y, b.Max.X, b.Max.Y),
+		image.Rect(b.Min.X, rect.Min.Y, rect.Min.X, rect.Max.Y),
+		image.Rect(rect.Max.X, rect.Min.Y, b.Max.X, rect.Max.Y),
+	} {
+		draw.Draw(e.preview, r.Intersect(b), dim, image.Point{}, draw.Over)
+	}
+}
+
+// cropTo crops the screenshot and its annotations to rect
+func (e *Editor) cropTo(rect image.Rectangle) {
+	rect = rect.Intersect(e.screenshot.Bounds())
+	if rect.Dx() < minCropSize || rect.Dy() < minCropSize {
+		e.updateCanvas()
+		return
+	}
+	e.applyTransform(tools.Crop(e.screenshot, rect))
+}
+
+// showResizeDialog asks for a new size as a percentage of the current one
+func (e *Editor) showResizeDialog() {
+	bounds := e.screenshot.Bounds()
+	percent := widget.NewEntry()
+	percent.SetText("50")
+	percent.Validator = func(s string) error {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v <= 0 || v > 400 {
+			return fmt.Errorf("enter a percentage between 1 and 400")
+		}
+		return nil
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Scale (%)", percent),
+		widget.NewFormItem("Current size", widget.NewLabel(fmt.Sprintf("%d × %d px", bounds.Dx(), bounds.Dy()))),
+	}
+
+	dialog.ShowForm("Resize Image", "Resize", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		v, err := strconv.ParseFloat(percent.Text, 64)
+		if err != nil {
+			return
+		}
+		width := max(1, int(float64(bounds.Dx())*v/100))
+		height := max(1, int(float64(bounds.Dy())*v/100))
+		e.applyTransform(tools.Resize(e.screenshot, width, height))
+	}, e.window)
+}
+
+// applyTransform replaces the screenshot and moves every annotation to match
+func (e *Editor) applyTransform(img *image.RGBA, t tools.Transform) {
+	e.screenshot = img
+	for _, ann := range e.annotations {
+		ann.Transform(t)
+	}
+
+	e.overlay = image.NewRGBA(img.Bounds())
+	e.preview = image.NewRGBA(img.Bounds())
+	e.updateCanvas()
+	e.sizeToImage()
+}
@@ -0,0 +1,149 @@
+package editor
+
+import (
+	"image/color"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+)
+
+// layerPanelWidth is the logical width of the layer list
+const layerPanelWidth = 240
+
+// layerItems returns the annotations as listed in the layer panel: topmost
+// first, the reverse of the order they are drawn in
+func (e *Editor) layerItems() []tools.Annotation {
+	items := tools.DrawOrder(e.annotations)
+	slices.Reverse(items)
+	return items
+}
+
+// layerLabel names an annotation's type for the layer list, e.g. "Double arrow"
+func layerLabel(a tools.Annotation) string {
+	name := strings.ReplaceAll(tools.TypeName(a), "_", " ")
+	if name == "" {
+		return "Annotation"
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// createLayerPanel creates the layer list, hidden until toggled from the
+// toolbar. Each row shows an annotation's colour and type, with buttons to
+// hide and lock it.
+func (e *Editor) createLayerPanel() fyne.CanvasObject {
+	e.layerList = widget.NewList(
+		func() int { return len(e.annotations) },
+		func() fyne.CanvasObject {
+			colour := canvas.NewRectangle(color.Transparent)
+			colour.StrokeColor = color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+			colour.StrokeWidth = 1
+			colour.SetMinSize(fyne.NewSize(14, 14))
+
+			hide := widget.NewButtonWithIcon("", theme.VisibilityIcon(), nil)
+			hide.Importance = widget.LowImportance
+			lock := widget.NewCheck("Lock", nil)
+
+			return container.NewHBox(container.NewCenter(colour), widget.NewLabel(""), layout.NewSpacer(), hide, lock)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			items := e.layerItems()
+			if id >= len(items) {
+				return
+			}
+			ann := items[id]
+			row := obj.(*fyne.Container)
+
+			colour := row.Objects[0].(*fyne.Container).Objects[0].(*canvas.Rectangle)
+			colour.FillColor = tools.ColorOf(ann)
+			colour.Refresh()
+
+			row.Objects[1].(*widget.Label).SetText(layerLabel(ann))
+
+			hide := row.Objects[3].(*widget.Button)
+			hide.SetIcon(theme.VisibilityIcon())
+			if tools.IsHidden(ann) {
+				hide.SetIcon(theme.VisibilityOffIcon())
+			}
+			hide.OnTapped = func() {
+				tools.SetHidden(ann, !tools.IsHidden(ann))
+				e.updateCanvas()
+			}
+
+			lock := row.Objects[4].(*widget.Check)
+			lock.OnChanged = nil
+			lock.SetChecked(tools.IsLocked(ann))
+			lock.OnChanged = func(locked bool) {
+				tools.SetLocked(ann, locked)
+				e.updateCanvas()
+			}
+		},
+	)
+	e.layerList.OnSelected = func(id widget.ListItemID) {
+		items := e.layerItems()
+		if id < len(items) && items[id] != e.selected {
+			e.selected = items[id]
+			e.updateCanvas()
+		}
+	}
+
+	order := func(icon fyne.Resource, move func([]tools.Annotation, tools.Annotation) bool) *widget.Button {
+		return widget.NewButtonWithIcon("", icon, func() { e.reorderSelected(move) })
+	}
+	buttons := container.NewGridWithColumns(4,
+		order(theme.UploadIcon(), tools.BringToFront),
+		order(theme.MoveUpIcon(), tools.BringForward),
+		order(theme.MoveDownIcon(), tools.SendBackward),
+		order(theme.DownloadIcon(), tools.SendToBack),
+	)
+
+	header := widget.NewLabelWithStyle("Layers", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	panel := container.NewBorder(header, buttons, nil, nil, e.layerList)
+
+	// The list has no width of its own, so a spacer holds the panel open
+	width := canvas.NewRectangle(color.Transparent)
+	width.SetMinSize(fyne.NewSize(layerPanelWidth, 0))
+	e.layerPanel = container.NewStack(width, panel)
+	e.layerPanel.Hide()
+	return e.layerPanel
+}
+
+// toggleLayerPanel shows or hides the layer list
+func (e *Editor) toggleLayerPanel() {
+	if e.layerPanel.Visible() {
+		e.layerPanel.Hide()
+	} else {
+		e.layerPanel.Show()
+		e.refreshLayers()
+	}
+}
+
+// refreshLayers updates the layer list to match the annotations and selection
+func (e *Editor) refreshLayers() {
+	if e.layerList == nil || !e.layerPanel.Visible() {
+		return
+	}
+	e.layerList.Refresh()
+	if i := slices.Index(e.layerItems(), e.selected); i >= 0 {
+		e.layerList.Select(i)
+	} else {
+		e.layerList.UnselectAll()
+	}
+}
+
+// reorderSelected moves the selected annotation within the draw order
+func (e *Editor) reorderSelected(move func([]tools.Annotation, tools.Annotation) bool) {
+	if e.selected == nil {
+		return
+	}
+	if move(e.annotations, e.selected) {
+		e.updateCanvas()
+	}
+}
@@ -0,0 +1,175 @@
+package editor
+
+import (
+	"image/color"
+	"log"
+	"slices"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+)
+
+// swatchSize is the logical size of a colour swatch
+const swatchSize = 22
+
+// defaultPalette is the set of colours always offered in the palette
+var defaultPalette = []string{
+	"#ff3b30", // red
+	"#ff9500", // orange
+	"#ffcc00", // yellow
+	"#34c759", // green
+	"#007aff", // blue
+	"#af52de", // purple
+	"#000000", // black
+	"#ffffff", // white
+}
+
+// strokeWidths are the logical stroke widths offered in the toolbar
+var strokeWidths = []int{1, 2, 3, 5, 8, 12}
+
+// swatch is a tappable square filled with a colour
+type swatch struct {
+	widget.BaseWidget
+	rect  *canvas.Rectangle
+	onTap func()
+}
+
+func newSwatch(c color.Color, onTap func()) *swatch {
+	rect := canvas.NewRectangle(c)
+	rect.StrokeColor = color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+	rect.StrokeWidth = 1
+	rect.SetMinSize(fyne.NewSize(swatchSize, swatchSize))
+
+	s := &swatch{rect: rect, onTap: onTap}
+	s.ExtendBaseWidget(s)
+	return s
+}
+
+// SetColor changes the colour shown by the swatch
+func (s *swatch) SetColor(c color.Color) {
+	s.rect.FillColor = c
+	s.rect.Refresh()
+}
+
+func (s *swatch) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(s.rect)
+}
+
+func (s *swatch) Tapped(ev *fyne.PointEvent) {
+	if s.onTap != nil {
+		s.onTap()
+	}
+}
+
+// setColor makes c the current tool colour and remembers it in the config
+func (e *Editor) setColor(c color.Color) {
+	hex := tools.HexColor(c)
+	e.toolColor, _ = tools.ParseHexColor(hex)
+	e.cfg.ToolColor = hex
+	e.cfg.AddRecentColor(hex)
+	e.saveConfig()
+
+	if e.colorSwatch != nil {
+		e.colorSwatch.SetColor(e.toolColor)
+	}
+}
+
+// setStrokeWidth changes the logical stroke width and remembers it in the config
+func (e *Editor) setStrokeWidth(width int) {
+	e.strokeWidth = width
+	e.cfg.StrokeWidth = width
+	e.saveConfig()
+}
+
+// strokePixels returns the current stroke width in image pixels
+func (e *Editor) strokePixels() int {
+	return max(1, int(float64(e.strokeWidth)*e.scaleFactor))
+}
+
+// saveConfig persists the config, logging rather than interrupting on failure
+func (e *Editor) saveConfig() {
+	if err := e.cfg.Save(); err != nil {
+		log.Printf("Failed to save config: %v", err)
+	}
+}
+
+// showPalette pops up the colour palette below the current colour swatch
+func (e *Editor) showPalette() {
+	var popup *widget.PopUp
+	pick := func(hex string) func() {
+		return func() {
+			if c, err := tools.ParseHexColor(hex); err == nil {
+				e.setColor(c)
+			}
+			popup.Hide()
+		}
+	}
+
+	swatches := func(colors []string) *fyne.Container {
+		row := container.NewGridWrap(fyne.NewSize(swatchSize, swatchSize))
+		for _, hex := range colors {
+			c, err := tools.ParseHexColor(hex)
+			if err != nil {
+				continue
+			}
+			row.Add(newSwatch(c, pick(hex)))
+		}
+		return row
+	}
+
+	addCustom := widget.NewButtonWithIcon("", theme.ContentAddIcon(), func() {
+		popup.Hide()
+		picker := dialog.NewColorPicker("Custom Colour", "Choose a colour to add to the palette", func(c color.Color) {
+			e.cfg.AddCustomColor(tools.HexColor(c))
+			e.setColor(c)
+		}, e.window)
+		picker.Advanced = true
+		picker.SetColor(e.toolColor)
+		picker.Show()
+	})
+
+	content := container.NewVBox(
+		widget.NewLabel("Palette"),
+		swatches(defaultPalette),
+		widget.NewLabel("Custom"),
+		container.NewHBox(swatches(e.cfg.CustomColors), addCustom),
+	)
+	if len(e.cfg.RecentColors) > 0 {
+		content.Add(widget.NewLabel("Recent"))
+		content.Add(swatches(e.cfg.RecentColors))
+	}
+
+	popup = widget.NewPopUp(content, e.window.Canvas())
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(e.colorSwatch)
+	popup.ShowAtPosition(pos.Add(fyne.NewPos(0, e.colorSwatch.Size().Height)))
+}
+
+// createStrokeSelect creates the stroke width selector
+func (e *Editor) createStrokeSelect() *widget.Select {
+	widths := strokeWidths
+	if !slices.Contains(widths, e.strokeWidth) {
+		widths = append(slices.Clone(widths), e.strokeWidth)
+		slices.Sort(widths)
+	}
+
+	options := make([]string, len(widths))
+	for i, w := range widths {
+		options[i] = strconv.Itoa(w)
+	}
+
+	sel := widget.NewSelect(options, nil)
+	sel.SetSelected(strconv.Itoa(e.strokeWidth))
+	sel.OnChanged = func(value string) {
+		if width, err := strconv.Atoi(value); err == nil {
+			e.setStrokeWidth(width)
+		}
+	}
+	return sel
+}
@@ -0,0 +1,92 @@
+//go:build darwin
+
+package editor
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+
+#import <Cocoa/Cocoa.h>
+
+extern void editorPinched(uintptr_t window, double magnification, double x, double y);
+
+static id pinchMonitor = nil;
+
+// WatchPinch passes trackpad pinches in any of the app's windows to Go,
+// with the pointer's position from the top left of the window's content
+void WatchPinch() {
+    dispatch_async(dispatch_get_main_queue(), ^{
+        if (pinchMonitor != nil) {
+            return;
+        }
+        pinchMonitor = [[NSEvent addLocalMonitorForEventsMatchingMask:NSEventMaskMagnify handler:^NSEvent *(NSEvent *event) {
+            NSWindow *window = [event window];
+            NSView *content = [window contentView];
+            if (content == nil) {
+                return event;
+            }
+            NSPoint p = [content convertPoint:[event locationInWindow] fromView:nil];
+            CGFloat y = [content isFlipped] ? p.y : NSHeight([content bounds]) - p.y;
+            editorPinched((uintptr_t)window, [event magnification], p.x, y);
+            return event;
+        }] retain];
+    });
+}
+*/
+import "C"
+
+import (
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver"
+)
+
+var (
+	pinchOnce sync.Once
+
+	// pinchEditors are the open editors that pinches are passed to
+	pinchMu      sync.Mutex
+	pinchEditors = map[*Editor]bool{}
+)
+
+// watchPinch zooms the editor with trackpad pinches. Fyne doesn't report
+// gestures, so they are picked up from AppKit directly.
+func (e *Editor) watchPinch() {
+	pinchOnce.Do(func() { C.WatchPinch() })
+
+	pinchMu.Lock()
+	pinchEditors[e] = true
+	pinchMu.Unlock()
+	e.window.SetOnClosed(func() {
+		pinchMu.Lock()
+		delete(pinchEditors, e)
+		pinchMu.Unlock()
+	})
+}
+
+//export editorPinched
+func editorPinched(window C.uintptr_t, magnification, x, y C.double) {
+	pinchMu.Lock()
+	defer pinchMu.Unlock()
+	for e := range pinchEditors {
+		native, ok := e.window.(driver.NativeWindow)
+		if !ok {
+			continue
+		}
+		var match bool
+		native.RunNative(func(ctx any) {
+			mac, ok := ctx.(driver.MacWindowContext)
+			match = ok && mac.NSWindow == uintptr(window)
+		})
+		if !match {
+			continue
+		}
+
+		// AppKit gives points; the canvas may be scaled beyond that
+		scale := e.window.Canvas().Scale()
+		pos := fyne.NewPos(float32(x)/scale, float32(y)/scale)
+		fyne.Do(func() { e.pinchZoom(float64(magnification), pos) })
+		return
+	}
+}
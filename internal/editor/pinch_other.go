@@ -0,0 +1,7 @@
+//go:build !darwin
+
+package editor
+
+// watchPinch does nothing on platforms other than macOS, where Fyne doesn't
+// report pinch gestures; Ctrl+scroll zooms instead
+func (e *Editor) watchPinch() {}
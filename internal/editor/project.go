@@ -0,0 +1,137 @@
+package editor
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	nativedialog "github.com/sqweek/dialog"
+
+	"github.com/owenrumney/schnappit/internal/output"
+	"github.com/owenrumney/schnappit/internal/project"
+)
+
+// showProjectMenu pops up the project file menu below anchor
+func (e *Editor) showProjectMenu(anchor fyne.CanvasObject) {
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("Open Project…", e.openProject),
+		fyne.NewMenuItem("Save Project…", e.saveProject),
+	)
+
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(anchor)
+	widget.ShowPopUpMenuAtPosition(menu, e.window.Canvas(), pos.Add(fyne.NewPos(0, anchor.Size().Height)))
+}
+
+// currentProject captures the editor's screenshot and annotations as a project
+func (e *Editor) currentProject() *project.Project {
+	return &project.Project{
+		Screenshot:  e.screenshot,
+		Annotations: e.annotations,
+		Metadata: project.Metadata{
+			CapturedAt:  e.capturedAt,
+			ScaleFactor: e.scaleFactor,
+		},
+	}
+}
+
+// saveProject saves the screenshot and its editable annotations using the
+// native save dialog. Unlike exporting, the editor stays open.
+func (e *Editor) saveProject() {
+	defaultDir, defaultFile := e.defaultSavePath(project.Extension)
+
+	path, err := nativedialog.File().
+		Filter("Schnappit Project", strings.TrimPrefix(project.Extension, ".")).
+		SetStartDir(defaultDir).
+		SetStartFile(defaultFile).
+		Title("Save Project").
+		Save()
+	if err != nil {
+		if err == nativedialog.ErrCancelled {
+			return
+		}
+		dialog.ShowError(fmt.Errorf("failed to save project: %w", err), e.window)
+		return
+	}
+	if !strings.HasSuffix(path, project.Extension) {
+		path += project.Extension
+	}
+
+	if err := project.Save(e.currentProject(), path); err != nil {
+		dialog.ShowError(fmt.Errorf("failed to save project: %w", err), e.window)
+	}
+}
+
+// openProject replaces the editor's contents with a saved project, or a
+// PNG exported with its project embedded. Any other image is opened
+// without annotations.
+func (e *Editor) openProject() {
+	defaultDir := e.outputDir()
+
+	path, err := nativedialog.File().
+		Filter("Schnappit Project", strings.TrimPrefix(project.Extension, ".")).
+		Filter("Images", "png", "jpg", "jpeg").
+		SetStartDir(defaultDir).
+		Title("Open Project").
+		Load()
+	if err != nil {
+		if err == nativedialog.ErrCancelled {
+			return
+		}
+		dialog.ShowError(fmt.Errorf("failed to open project: %w", err), e.window)
+		return
+	}
+
+	var p *project.Project
+	if strings.HasSuffix(path, project.Extension) {
+		p, err = project.Open(path)
+	} else {
+		p, err = openImageProject(path, e.scaleFactor)
+	}
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to open project: %w", err), e.window)
+		return
+	}
+	e.loadProject(p)
+}
+
+// openImageProject returns the project embedded in an image, or a new
+// project with no annotations if it has none
+func openImageProject(path string, scaleFactor float64) (*project.Project, error) {
+	img, p, err := output.LoadImage(path)
+	if err != nil || p != nil {
+		return p, err
+	}
+	screenshot := image.NewRGBA(image.Rectangle{Max: img.Bounds().Size()})
+	draw.Draw(screenshot, screenshot.Bounds(), img, img.Bounds().Min, draw.Src)
+	return &project.Project{
+		Screenshot: screenshot,
+		Metadata:   project.Metadata{ScaleFactor: scaleFactor},
+	}, nil
+}
+
+// loadProject shows a project's screenshot and annotations in the editor
+func (e *Editor) loadProject(p *project.Project) {
+	e.screenshot = p.Screenshot
+	e.annotations = p.Annotations
+	e.capturedAt = p.Metadata.CapturedAt
+	e.source = output.CaptureInfo{} // The capture's window isn't recorded
+	if p.Metadata.ScaleFactor > 0 {
+		e.scaleFactor = p.Metadata.ScaleFactor
+	}
+	if e.capturedAt.IsZero() {
+		e.capturedAt = time.Now()
+	}
+
+	e.drawing = false
+	e.adjusting = nil
+	e.selected = nil
+	e.overlay = image.NewRGBA(e.screenshot.Bounds())
+	e.preview = image.NewRGBA(e.screenshot.Bounds())
+	e.updateCanvas()
+	e.sizeToImage()
+}
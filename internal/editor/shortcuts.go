@@ -0,0 +1,277 @@
+package editor
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+)
+
+// shortcutAction is an editor command that can be bound to keys in the config
+type shortcutAction struct {
+	name  string // Key in config.Shortcuts
+	label string // Shown in the help overlay
+	run   func()
+}
+
+// keyBinding is a single key combination
+type keyBinding struct {
+	key fyne.KeyName
+	mod fyne.KeyModifier
+}
+
+// shortcutModifiers maps config names to key modifiers
+var shortcutModifiers = map[string]fyne.KeyModifier{
+	"mod":     fyne.KeyModifierShortcutDefault,
+	"cmd":     fyne.KeyModifierSuper,
+	"command": fyne.KeyModifierSuper,
+	"super":   fyne.KeyModifierSuper,
+	"ctrl":    fyne.KeyModifierControl,
+	"control": fyne.KeyModifierControl,
+	"shift":   fyne.KeyModifierShift,
+	"alt":     fyne.KeyModifierAlt,
+	"option":  fyne.KeyModifierAlt,
+	"opt":     fyne.KeyModifierAlt,
+}
+
+// shortcutKeys maps config names to keys, beyond single letters and digits
+var shortcutKeys = map[string]fyne.KeyName{
+	"escape":       fyne.KeyEscape,
+	"esc":          fyne.KeyEscape,
+	"delete":       fyne.KeyDelete,
+	"backspace":    fyne.KeyBackspace,
+	"return":       fyne.KeyReturn,
+	"enter":        fyne.KeyReturn,
+	"tab":          fyne.KeyTab,
+	"space":        fyne.KeySpace,
+	"slash":        fyne.KeySlash,
+	"minus":        fyne.KeyMinus,
+	"equal":        fyne.KeyEqual,
+	"bracketleft":  fyne.KeyLeftBracket,
+	"bracketright": fyne.KeyRightBracket,
+	"plus":         fyne.KeyPlus,
+	"left":         fyne.KeyLeft,
+	"right":        fyne.KeyRight,
+	"up":           fyne.KeyUp,
+	"down":         fyne.KeyDown,
+	"f1":           fyne.KeyF1, "f2": fyne.KeyF2, "f3": fyne.KeyF3, "f4": fyne.KeyF4,
+	"f5": fyne.KeyF5, "f6": fyne.KeyF6, "f7": fyne.KeyF7, "f8": fyne.KeyF8,
+	"f9": fyne.KeyF9, "f10": fyne.KeyF10, "f11": fyne.KeyF11, "f12": fyne.KeyF12,
+}
+
+// parseShortcut parses a shortcut like "mod+shift+s", or several separated
+// by commas, into key bindings
+func parseShortcut(s string) ([]keyBinding, error) {
+	var bindings []keyBinding
+	for _, combo := range strings.Split(s, ",") {
+		combo = strings.TrimSpace(strings.ToLower(combo))
+		if combo == "" {
+			continue
+		}
+
+		var b keyBinding
+		for _, part := range strings.Split(combo, "+") {
+			part = strings.TrimSpace(part)
+			if mod, ok := shortcutModifiers[part]; ok {
+				b.mod |= mod
+				continue
+			}
+			if b.key != "" {
+				return nil, fmt.Errorf("multiple keys specified in shortcut: %s", combo)
+			}
+			switch {
+			case len(part) == 1 && part[0] >= 'a' && part[0] <= 'z',
+				len(part) == 1 && part[0] >= '0' && part[0] <= '9':
+				b.key = fyne.KeyName(strings.ToUpper(part))
+			default:
+				key, ok := shortcutKeys[part]
+				if !ok {
+					return nil, fmt.Errorf("unknown key in shortcut: %s", part)
+				}
+				b.key = key
+			}
+		}
+		if b.key == "" {
+			return nil, fmt.Errorf("no key specified in shortcut: %s", combo)
+		}
+		bindings = append(bindings, b)
+	}
+	return bindings, nil
+}
+
+// formatShortcut makes a config shortcut readable for the help overlay
+func formatShortcut(s string) string {
+	modName := "Ctrl"
+	if runtime.GOOS == "darwin" {
+		modName = "Cmd"
+	}
+
+	var combos []string
+	for _, combo := range strings.Split(s, ",") {
+		var parts []string
+		for _, part := range strings.Split(strings.TrimSpace(combo), "+") {
+			part = strings.TrimSpace(part)
+			switch strings.ToLower(part) {
+			case "":
+				continue
+			case "mod":
+				part = modName
+			case "slash":
+				part = "/"
+			case "equal":
+				part = "="
+			case "minus":
+				part = "-"
+			case "bracketleft":
+				part = "["
+			case "bracketright":
+				part = "]"
+			default:
+				part = strings.ToUpper(part[:1]) + part[1:]
+			}
+			parts = append(parts, part)
+		}
+		if len(parts) > 0 {
+			combos = append(combos, strings.Join(parts, "+"))
+		}
+	}
+	return strings.Join(combos, " or ")
+}
+
+// canvasShortcut returns the shortcut the driver raises for a key binding
+// with modifiers. Common editing combinations arrive as Fyne's built-in
+// shortcuts rather than as custom desktop ones.
+func canvasShortcut(b keyBinding) fyne.Shortcut {
+	if b.mod == fyne.KeyModifierShortcutDefault {
+		switch b.key {
+		case fyne.KeyC:
+			return &fyne.ShortcutCopy{}
+		case fyne.KeyV:
+			return &fyne.ShortcutPaste{}
+		case fyne.KeyX:
+			return &fyne.ShortcutCut{}
+		case fyne.KeyA:
+			return &fyne.ShortcutSelectAll{}
+		case fyne.KeyZ:
+			return &fyne.ShortcutUndo{}
+		case fyne.KeyY:
+			return &fyne.ShortcutRedo{}
+		}
+	}
+	return &desktop.CustomShortcut{KeyName: b.key, Modifier: b.mod}
+}
+
+// shortcutActions returns every action that can be bound to a key, in the
+// order they are listed in the help overlay
+func (e *Editor) shortcutActions() []shortcutAction {
+	var actions []shortcutAction
+	for _, tool := range tools.Tools() {
+		actions = append(actions, shortcutAction{
+			name:  "tool." + tool.Name,
+			label: tool.Label,
+			run:   func() { e.selectTool(tool.Name) },
+		})
+	}
+
+	actions = append(actions, []shortcutAction{
+		{"copy", "Copy to clipboard", e.copyToClipboard},
+		{"save", "Save to file", e.saveToFile},
+		{"upload", "Upload and copy the link", e.upload},
+		{"close", "Close editor", e.window.Close},
+		{"delete", "Delete selected annotation", e.deleteSelected},
+		{"help", "Show keyboard shortcuts", e.showShortcutHelp},
+		{"zoom.in", "Zoom in", func() { e.zoomBy(zoomStep) }},
+		{"zoom.out", "Zoom out", func() { e.zoomBy(1 / zoomStep) }},
+		{"zoom.fit", "Fit to window", e.zoomToFit},
+		{"zoom.actual", "Actual size (100%)", func() { e.zoomToPercent(100) }},
+		{"layer.forward", "Bring selected forward", func() { e.reorderSelected(tools.BringForward) }},
+		{"layer.backward", "Send selected backward", func() { e.reorderSelected(tools.SendBackward) }},
+		{"layer.front", "Bring selected to front", func() { e.reorderSelected(tools.BringToFront) }},
+		{"layer.back", "Send selected to back", func() { e.reorderSelected(tools.SendToBack) }},
+	}...)
+	for i, hex := range defaultPalette {
+		actions = append(actions, shortcutAction{
+			name:  fmt.Sprintf("color.%d", i+1),
+			label: fmt.Sprintf("Palette colour %d (%s)", i+1, hex),
+			run: func() {
+				if c, err := tools.ParseHexColor(hex); err == nil {
+					e.setColor(c)
+				}
+			},
+		})
+	}
+	return actions
+}
+
+// binding returns the keys bound to an action. Tools that the config
+// doesn't mention keep the default shortcut they were registered with.
+func (e *Editor) binding(name string) string {
+	if keys, ok := e.cfg.Shortcuts[name]; ok {
+		return keys
+	}
+	if toolName, ok := strings.CutPrefix(name, "tool."); ok {
+		if tool := tools.LookupTool(toolName); tool != nil {
+			return tool.Shortcut
+		}
+	}
+	return ""
+}
+
+// registerShortcuts binds the configured shortcuts to the editor's canvas.
+// Combinations with Cmd, Ctrl or Alt are registered as canvas shortcuts;
+// plain and Shift-only keys are matched as they are typed.
+func (e *Editor) registerShortcuts(c fyne.Canvas) {
+	e.keyActions = make(map[keyBinding]func())
+
+	for _, action := range e.shortcutActions() {
+		bindings, err := parseShortcut(e.binding(action.name))
+		if err != nil {
+			log.Printf("Invalid shortcut for %s: %v", action.name, err)
+			continue
+		}
+
+		run := action.run
+		for _, b := range bindings {
+			if b.mod&^fyne.KeyModifierShift == 0 {
+				e.keyActions[b] = run
+				continue
+			}
+			c.AddShortcut(canvasShortcut(b), func(fyne.Shortcut) { run() })
+		}
+	}
+
+	c.SetOnTypedKey(func(ev *fyne.KeyEvent) {
+		b := keyBinding{key: ev.Name}
+		if e.shiftDown {
+			b.mod = fyne.KeyModifierShift
+		}
+		if run, ok := e.keyActions[b]; ok {
+			run()
+		}
+	})
+}
+
+// showShortcutHelp shows an overlay listing every bound shortcut
+func (e *Editor) showShortcutHelp() {
+	grid := container.NewGridWithColumns(2)
+	for _, action := range e.shortcutActions() {
+		binding := e.binding(action.name)
+		if strings.TrimSpace(binding) == "" {
+			continue
+		}
+		grid.Add(widget.NewLabel(action.label))
+		grid.Add(widget.NewLabelWithStyle(formatShortcut(binding), fyne.TextAlignTrailing, fyne.TextStyle{Monospace: true}))
+	}
+
+	scroll := container.NewVScroll(grid)
+	scroll.SetMinSize(fyne.NewSize(380, 420))
+	dialog.ShowCustom("Keyboard Shortcuts", "Close", scroll, e.window)
+}
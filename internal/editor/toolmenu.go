@@ -0,0 +1,103 @@
+package editor
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+)
+
+// showToolMenu pops up the tool's options below anchor. Choosing one of an
+// option's values also selects the tool.
+func (e *Editor) showToolMenu(tool *tools.Tool, anchor fyne.CanvasObject) {
+	settings := e.toolSettings(tool)
+
+	var items []*fyne.MenuItem
+	for i, o := range tool.Options {
+		if i > 0 {
+			items = append(items, fyne.NewMenuItemSeparator())
+		}
+		_, toggle := o.Default.(bool)
+		switch {
+		case toggle:
+			on, _ := settings[o.Name].(bool)
+			item := fyne.NewMenuItem(o.Label, func() {
+				e.setToolSetting(tool, o.Name, !on)
+			})
+			item.Checked = on
+			items = append(items, item)
+		case len(o.Choices) > 0:
+			for _, c := range o.Choices {
+				item := fyne.NewMenuItem(c.Label, func() {
+					e.setToolSetting(tool, o.Name, c.Value)
+					e.selectTool(tool.Name)
+				})
+				item.Checked = settings[o.Name] == c.Value
+				items = append(items, item)
+			}
+		default:
+			items = append(items, fyne.NewMenuItem(o.Label+"…", func() {
+				e.showToolSliderDialog(tool, o)
+			}))
+		}
+	}
+
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(anchor)
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), e.window.Canvas(), pos.Add(fyne.NewPos(0, anchor.Size().Height)))
+}
+
+// showToolSliderDialog asks for the value of a numeric tool option
+func (e *Editor) showToolSliderDialog(tool *tools.Tool, o tools.Option) {
+	unit := 1.0
+	if o.Percent {
+		unit = 100
+	}
+
+	value := widget.NewLabel("")
+	slider := widget.NewSlider(o.Min*unit, o.Max*unit)
+	if o.Step > 0 {
+		slider.Step = o.Step * unit
+	}
+	slider.OnChanged = func(v float64) {
+		if o.Percent {
+			value.SetText(fmt.Sprintf("%.0f%%", v))
+		} else {
+			value.SetText(fmt.Sprintf("%g", v))
+		}
+	}
+	current, _ := e.toolSettings(tool)[o.Name].(float64)
+	slider.SetValue(current * unit)
+
+	content := container.NewBorder(nil, nil, nil, value, slider)
+	dialog.ShowCustomConfirm(o.Label, "Apply", "Cancel", content, func(ok bool) {
+		if ok {
+			e.setToolSetting(tool, o.Name, slider.Value/unit)
+		}
+	}, e.window)
+}
+
+// toolSettings returns the tool's options as configured
+func (e *Editor) toolSettings(tool *tools.Tool) tools.Settings {
+	return tool.Settings(e.cfg.ToolSettings[tool.Name])
+}
+
+// setToolSetting saves a changed tool option and applies it to what the
+// tool has already drawn
+func (e *Editor) setToolSetting(tool *tools.Tool, name string, value any) {
+	settings := e.toolSettings(tool)
+	settings[name] = value
+	if e.cfg.ToolSettings == nil {
+		e.cfg.ToolSettings = make(map[string]map[string]any)
+	}
+	e.cfg.ToolSettings[tool.Name] = settings
+	e.saveConfig()
+
+	if tool.Restyle != nil {
+		tool.Restyle(e.toolContext(tool))
+		e.updateCanvas()
+	}
+}
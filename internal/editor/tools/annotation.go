@@ -1,10 +1,10 @@
 package tools
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
-	"math"
 )
 
 // Annotation represents a drawable annotation on the screenshot
@@ -17,19 +17,86 @@ type Annotation interface {
 
 	// Contains returns true if the point is within the annotation
 	Contains(x, y int) bool
+
+	// Transform moves the annotation to follow a change to the image geometry
+	Transform(t Transform)
+
+	// SVG returns the annotation as native SVG elements. id is unique within
+	// the document, for naming any definitions the elements need.
+	SVG(id string) string
+}
+
+// underlay is implemented by annotations that restyle the screenshot itself,
+// such as spotlights, and so must sit beneath every other annotation
+type underlay interface {
+	underlay()
+}
+
+// DrawOrder returns annotations in the order they are drawn: underlays
+// first, then everything else, each keeping their relative order. This way
+// dimming the screenshot never dims the arrows and shapes on top of it.
+func DrawOrder(annotations []Annotation) []Annotation {
+	ordered := make([]Annotation, 0, len(annotations))
+	for _, ann := range annotations {
+		if _, ok := ann.(underlay); ok {
+			ordered = append(ordered, ann)
+		}
+	}
+	for _, ann := range annotations {
+		if _, ok := ann.(underlay); !ok {
+			ordered = append(ordered, ann)
+		}
+	}
+	return ordered
+}
+
+// AnnotationAt returns the topmost visible, unlocked annotation containing
+// the point, or nil
+func AnnotationAt(annotations []Annotation, x, y int) Annotation {
+	ordered := DrawOrder(annotations)
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if IsHidden(ordered[i]) || IsLocked(ordered[i]) {
+			continue
+		}
+		if ordered[i].Contains(x, y) {
+			return ordered[i]
+		}
+	}
+	return nil
+}
+
+// Render draws the visible annotations onto img in DrawOrder
+func Render(img *image.RGBA, annotations []Annotation) {
+	for _, ann := range DrawOrder(annotations) {
+		if !IsHidden(ann) {
+			ann.Draw(img)
+		}
+	}
 }
 
 // BaseAnnotation contains common annotation properties
 type BaseAnnotation struct {
-	Color       color.Color
-	StrokeWidth int
+	// Color's alpha is the annotation's opacity. Annotations are blended
+	// onto what's beneath them, so a translucent colour lets it show through.
+	Color       color.Color `json:"-"`
+	StrokeWidth int         `json:"stroke_width"`
+
+	// Hidden annotations are not drawn or exported. Locked annotations are
+	// drawn but can't be selected or adjusted on the canvas.
+	Hidden bool `json:"hidden,omitempty"`
+	Locked bool `json:"locked,omitempty"`
+}
+
+// transformStroke scales the stroke width along with the image
+func (b *BaseAnnotation) transformStroke(t Transform) {
+	b.StrokeWidth = t.ApplyLength(b.StrokeWidth)
 }
 
 // ArrowAnnotation represents an arrow annotation
 type ArrowAnnotation struct {
 	BaseAnnotation
-	Start image.Point
-	End   image.Point
+	Start image.Point `json:"start"`
+	End   image.Point `json:"end"`
 }
 
 // NewArrow creates a new arrow annotation
@@ -43,8 +110,11 @@ func NewArrow(start, end image.Point, c color.Color, strokeWidth int) *ArrowAnno
 
 // Draw renders the arrow onto the image
 func (a *ArrowAnnotation) Draw(img *image.RGBA) {
-	drawLine(img, a.Start, a.End, a.Color, a.StrokeWidth)
-	drawArrowHead(img, a.Start, a.End, a.Color, a.StrokeWidth)
+	// The head can extend past Bounds, so give the shape room for it
+	s := newShape(img, a.Bounds().Inset(-a.StrokeWidth*6))
+	shaftEnd := s.arrowHead(pt(a.Start), pt(a.End), a.StrokeWidth)
+	s.polyline([]fpoint{pt(a.Start), shaftEnd}, float64(a.StrokeWidth))
+	s.draw(img, a.Color)
 }
 
 // Bounds returns the bounding box of the arrow
@@ -61,11 +131,23 @@ func (a *ArrowAnnotation) Contains(x, y int) bool {
 	return image.Pt(x, y).In(a.Bounds())
 }
 
+// Transform moves the arrow to follow a change to the image geometry
+func (a *ArrowAnnotation) Transform(t Transform) {
+	a.Start, a.End = t.Apply(a.Start), t.Apply(a.End)
+	a.transformStroke(t)
+}
+
+// SVG returns the arrow as a line and a solid head
+func (a *ArrowAnnotation) SVG(id string) string {
+	head, shaftEnd := arrowHeadPoints(pt(a.Start), pt(a.End), a.StrokeWidth)
+	return "<g>" + svgLine(pt(a.Start), shaftEnd, a.Color, float64(a.StrokeWidth), "round") + svgPolygon(head, a.Color) + "</g>"
+}
+
 // RectAnnotation represents a rectangle annotation
 type RectAnnotation struct {
 	BaseAnnotation
-	Rect   image.Rectangle
-	Filled bool
+	Rect   image.Rectangle `json:"rect"`
+	Filled bool            `json:"filled"`
 }
 
 // NewRect creates a new rectangle annotation
@@ -82,7 +164,9 @@ func (r *RectAnnotation) Draw(img *image.RGBA) {
 	if r.Filled {
 		draw.Draw(img, r.Rect, &image.Uniform{r.Color}, image.Point{}, draw.Over)
 	} else {
-		drawRectOutline(img, r.Rect, r.Color, r.StrokeWidth)
+		s := newShape(img, r.Bounds())
+		strokeRect(s, r.Rect, float64(r.StrokeWidth))
+		s.draw(img, r.Color)
 	}
 }
 
@@ -96,71 +180,87 @@ func (r *RectAnnotation) Contains(x, y int) bool {
 	return image.Pt(x, y).In(r.Bounds())
 }
 
-func drawLine(img *image.RGBA, start, end image.Point, c color.Color, width int) {
-	dx := math.Abs(float64(end.X - start.X))
-	dy := math.Abs(float64(end.Y - start.Y))
-	sx, sy := 1, 1
-	if start.X >= end.X {
-		sx = -1
-	}
-	if start.Y >= end.Y {
-		sy = -1
-	}
-	err := dx - dy
-
-	x, y := start.X, start.Y
-	for {
-		for i := -width / 2; i <= width/2; i++ {
-			for j := -width / 2; j <= width/2; j++ {
-				if x+i >= 0 && y+j >= 0 && x+i < img.Bounds().Dx() && y+j < img.Bounds().Dy() {
-					img.Set(x+i, y+j, c)
-				}
-			}
-		}
+// HighlighterAnnotation represents a translucent marker stroke, like a
+// highlighter pen dragged over text
+type HighlighterAnnotation struct {
+	BaseAnnotation
+	Start image.Point `json:"start"`
+	End   image.Point `json:"end"`
+}
 
-		if x == end.X && y == end.Y {
-			break
-		}
-		e2 := 2 * err
-		if e2 > -dy {
-			err -= dy
-			x += sx
-		}
-		if e2 < dx {
-			err += dx
-			y += sy
-		}
+// NewHighlighter creates a new highlighter annotation
+func NewHighlighter(start, end image.Point, c color.Color, strokeWidth int) *HighlighterAnnotation {
+	return &HighlighterAnnotation{
+		BaseAnnotation: BaseAnnotation{Color: c, StrokeWidth: strokeWidth},
+		Start:          start,
+		End:            end,
 	}
 }
 
-func drawArrowHead(img *image.RGBA, start, end image.Point, c color.Color, strokeWidth int) {
-	headLength := strokeWidth * 5
-	headWidth := strokeWidth * 3
+// Draw renders the highlighter stroke onto the image
+func (h *HighlighterAnnotation) Draw(img *image.RGBA) {
+	s := newShape(img, h.Bounds())
+	s.segment(pt(h.Start), pt(h.End), float64(h.StrokeWidth), true)
+	s.draw(img, h.Color)
+}
 
-	dx := float64(end.X - start.X)
-	dy := float64(end.Y - start.Y)
-	length := math.Sqrt(dx*dx + dy*dy)
-	if length < 1 {
-		return
-	}
+// Bounds returns the bounding box of the highlighter stroke
+func (h *HighlighterAnnotation) Bounds() image.Rectangle {
+	minX := min(h.Start.X, h.End.X) - h.StrokeWidth
+	minY := min(h.Start.Y, h.End.Y) - h.StrokeWidth
+	maxX := max(h.Start.X, h.End.X) + h.StrokeWidth
+	maxY := max(h.Start.Y, h.End.Y) + h.StrokeWidth
+	return image.Rect(minX, minY, maxX, maxY)
+}
 
-	dx, dy = dx/length, dy/length
+// Contains returns true if the point is on or near the highlighter stroke
+func (h *HighlighterAnnotation) Contains(x, y int) bool {
+	return distanceToSegment(image.Pt(x, y), h.Start, h.End) <= float64(h.StrokeWidth)/2+hitSlop
+}
 
-	baseX := float64(end.X) - dx*float64(headLength)
-	baseY := float64(end.Y) - dy*float64(headLength)
+// Transform moves the highlighter stroke to follow a change to the image geometry
+func (h *HighlighterAnnotation) Transform(t Transform) {
+	h.Start, h.End = t.Apply(h.Start), t.Apply(h.End)
+	h.transformStroke(t)
+}
 
-	perpX, perpY := -dy, dx
+// SVG returns the highlighter stroke as a square-capped line
+func (h *HighlighterAnnotation) SVG(id string) string {
+	return svgLine(pt(h.Start), pt(h.End), h.Color, float64(h.StrokeWidth), "square")
+}
 
-	left := image.Pt(int(baseX+perpX*float64(headWidth)), int(baseY+perpY*float64(headWidth)))
-	right := image.Pt(int(baseX-perpX*float64(headWidth)), int(baseY-perpY*float64(headWidth)))
+// Transform moves the rectangle to follow a change to the image geometry
+func (r *RectAnnotation) Transform(t Transform) {
+	r.Rect = t.ApplyRect(r.Rect)
+	r.transformStroke(t)
+}
 
-	drawLine(img, end, left, c, strokeWidth)
-	drawLine(img, end, right, c, strokeWidth)
+// SVG returns the rectangle as an SVG rect
+func (r *RectAnnotation) SVG(id string) string {
+	if r.Filled {
+		min := fpoint{X: float64(r.Rect.Min.X), Y: float64(r.Rect.Min.Y)}
+		max := fpoint{X: float64(r.Rect.Max.X), Y: float64(r.Rect.Max.Y)}
+		return svgRect(min, max, svgPaint("fill", r.Color))
+	}
+	return svgRect(pt(r.Rect.Min), pt(r.Rect.Max), fmt.Sprintf(`fill="none" %s stroke-width="%d" stroke-linejoin="miter"`,
+		svgPaint("stroke", r.Color), r.StrokeWidth))
 }
 
-func drawRectOutline(img *image.RGBA, rect image.Rectangle, c color.Color, width int) {
-	drawLine(img, image.Pt(rect.Min.X, rect.Min.Y), image.Pt(rect.Max.X, rect.Min.Y), c, width)
-	drawLine(img, image.Pt(rect.Min.X, rect.Max.Y), image.Pt(rect.Max.X, rect.Max.Y), c, width)
-	drawLine(img, image.Pt(rect.Min.X, rect.Min.Y), image.Pt(rect.Min.X, rect.Max.Y), c, width)
-	drawLine(img, image.Pt(rect.Max.X, rect.Min.Y), image.Pt(rect.Max.X, rect.Max.Y), c, width)
+// strokeRect adds a rectangle outline with mitred corners, centred on the
+// rectangle's edges
+func strokeRect(s *shape, rect image.Rectangle, width float64) {
+	minP, maxP := pt(rect.Min), pt(rect.Max)
+	ring := func(inset float64, hole bool) {
+		s.polygon([]fpoint{
+			{minP.X + inset, minP.Y + inset},
+			{maxP.X - inset, minP.Y + inset},
+			{maxP.X - inset, maxP.Y - inset},
+			{minP.X + inset, maxP.Y - inset},
+		}, hole)
+	}
+
+	ring(-width/2, false)
+	if float64(rect.Dx()) > width && float64(rect.Dy()) > width {
+		ring(width/2, true)
+	}
 }
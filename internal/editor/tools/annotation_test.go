@@ -3,6 +3,7 @@ package tools
 import (
 	"image"
 	"image/color"
+	"image/draw"
 	"math"
 	"testing"
 )
@@ -248,3 +249,140 @@ func TestAbs(t *testing.T) {
 		}
 	}
 }
+
+func TestNewHighlighter(t *testing.T) {
+	start := image.Pt(10, 20)
+	end := image.Pt(100, 20)
+	c := color.NRGBA{R: 255, G: 230, A: 110}
+
+	h := NewHighlighter(start, end, c, 18)
+
+	if h.Start != start || h.End != end {
+		t.Errorf("Expected %v-%v, got %v-%v", start, end, h.Start, h.End)
+	}
+	if h.Color != c {
+		t.Errorf("Expected color %v, got %v", c, h.Color)
+	}
+	if h.StrokeWidth != 18 {
+		t.Errorf("Expected strokeWidth 18, got %d", h.StrokeWidth)
+	}
+}
+
+func TestHighlighterContains(t *testing.T) {
+	h := NewHighlighter(image.Pt(10, 10), image.Pt(110, 110), color.NRGBA{R: 255, A: 110}, 12)
+
+	tests := []struct {
+		name string
+		x    int
+		y    int
+		want bool
+	}{
+		{"on the stroke", 60, 60, true},
+		{"at the edge of the stroke", 64, 56, true},
+		{"inside bounds but off the stroke", 100, 20, false},
+		{"beyond the end", 130, 130, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.Contains(tt.x, tt.y); got != tt.want {
+				t.Errorf("Contains(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlighterDrawBlends(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{B: 255, A: 255}), image.Point{}, draw.Src)
+
+	h := NewHighlighter(image.Pt(10, 50), image.Pt(90, 50), color.NRGBA{R: 255, A: 128}, 9)
+	h.Draw(img)
+
+	// Half-transparent red over opaque blue should mix rather than replace
+	pixel := img.RGBAAt(50, 50)
+	if pixel.R < 120 || pixel.R > 136 {
+		t.Errorf("Expected red channel around 128, got %d", pixel.R)
+	}
+	if pixel.B < 120 || pixel.B > 136 {
+		t.Errorf("Expected blue channel around 127, got %d", pixel.B)
+	}
+	if pixel.A != 255 {
+		t.Errorf("Expected opaque result, got alpha %d", pixel.A)
+	}
+
+	// Overlapping brush stamps must not compound, so the stroke is uniform
+	if other := img.RGBAAt(20, 52); other != pixel {
+		t.Errorf("Expected uniform stroke, got %v and %v", pixel, other)
+	}
+
+	// Pixels outside the stroke are untouched
+	if outside := img.RGBAAt(50, 10); outside != (color.RGBA{B: 255, A: 255}) {
+		t.Errorf("Expected untouched pixel outside stroke, got %v", outside)
+	}
+}
+
+func TestTranslucentAnnotationsBlend(t *testing.T) {
+	background := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	translucent := color.NRGBA{R: 0, G: 0, B: 0, A: 64}
+
+	tests := []struct {
+		name string
+		ann  Annotation
+		x, y int
+	}{
+		{"arrow", NewArrow(image.Pt(10, 10), image.Pt(150, 150), translucent, 5), 80, 80},
+		{"rect outline", NewRect(image.Rect(50, 50, 150, 150), translucent, 5, false), 50, 100},
+		{"filled rect", NewRect(image.Rect(50, 50, 150, 150), translucent, 5, true), 100, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+			draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+			tt.ann.Draw(img)
+
+			pixel := img.RGBAAt(tt.x, tt.y)
+			if pixel == background {
+				t.Fatalf("Expected pixel at (%d,%d) to be drawn", tt.x, tt.y)
+			}
+			if pixel.R < 180 || pixel.R > 200 {
+				t.Errorf("Expected 25%% black over white (~191), got %v", pixel)
+			}
+		})
+	}
+}
+
+func TestDrawOrder(t *testing.T) {
+	rect := NewRect(image.Rect(0, 0, 10, 10), color.Black, 1, false)
+	line := NewLine(image.Pt(0, 0), image.Pt(10, 10), color.Black, 1)
+	spot := NewSpotlight(color.Black, 0.5, false)
+
+	got := DrawOrder([]Annotation{rect, spot, line})
+	if len(got) != 3 || got[0] != Annotation(spot) || got[1] != Annotation(rect) || got[2] != Annotation(line) {
+		t.Errorf("DrawOrder() = %v, want spotlight, rect, line", got)
+	}
+}
+
+func TestAnnotationAt(t *testing.T) {
+	below := NewRect(image.Rect(0, 0, 50, 50), color.Black, 1, false)
+	above := NewRect(image.Rect(20, 20, 40, 40), color.Black, 1, false)
+	spot := NewSpotlight(color.Black, 0.5, false, SpotlightRegion{Rect: image.Rect(0, 0, 100, 100)})
+	annotations := []Annotation{below, above, spot}
+
+	if got := AnnotationAt(annotations, 30, 30); got != Annotation(above) {
+		t.Errorf("AnnotationAt(30, 30) = %v, want the topmost rectangle", got)
+	}
+	if got := AnnotationAt(annotations, 10, 10); got != Annotation(below) {
+		t.Errorf("AnnotationAt(10, 10) = %v, want the lower rectangle", got)
+	}
+	// The spotlight is drawn beneath everything, so it is only hit where
+	// nothing else is
+	if got := AnnotationAt(annotations, 80, 80); got != Annotation(spot) {
+		t.Errorf("AnnotationAt(80, 80) = %v, want the spotlight", got)
+	}
+	if got := AnnotationAt(annotations, 200, 200); got != nil {
+		t.Errorf("AnnotationAt(200, 200) = %v, want nil", got)
+	}
+}
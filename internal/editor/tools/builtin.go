@@ -0,0 +1,233 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"slices"
+)
+
+// highlighterScale is how much wider the highlighter is than the chosen stroke width
+const highlighterScale = 6
+
+// spotlightRadius is the logical corner radius of rounded spotlight regions
+const spotlightRadius = 8
+
+// HighlighterColor is the yellow of the highlighter, which is drawn at the
+// opacity chosen in its menu so the text underneath shows through
+var HighlighterColor = color.NRGBA{R: 255, G: 230, B: 0, A: 255}
+
+// The built-in tools are registered together so that their toolbar order
+// doesn't depend on file names
+func init() {
+	RegisterTool(Tool{
+		Name: "arrow", Label: "Arrow tool", Icon: "arrowDown", Shortcut: "a",
+		Constrain: Constrain45,
+		Build: func(ctx *ToolContext, d Drag) Annotation {
+			return NewArrow(d.Start, d.End, ctx.Color, ctx.StrokeWidth)
+		},
+	})
+	RegisterTool(Tool{
+		Name: "rectangle", Label: "Rectangle tool", Icon: "unchecked", Shortcut: "r",
+		Build: func(ctx *ToolContext, d Drag) Annotation {
+			return NewRect(d.Rect(), ctx.Color, ctx.StrokeWidth, ctx.Filled)
+		},
+	})
+	RegisterTool(Tool{
+		Name: "highlighter", Label: "Highlighter tool", Icon: "colorPalette", Shortcut: "h",
+		Options: []Option{
+			{Name: "opacity", Default: 0.45, Min: 0.1, Max: 1, Choices: []Choice{
+				{Label: "30% Opacity", Value: 0.3},
+				{Label: "45% Opacity", Value: 0.45},
+				{Label: "60% Opacity", Value: 0.6},
+			}},
+		},
+		Build: func(ctx *ToolContext, d Drag) Annotation {
+			c := HighlighterColor
+			c.A = uint8(math.Round(Setting[float64](ctx, "opacity") * 255))
+			return NewHighlighter(d.Start, d.End, c, ctx.StrokeWidth*highlighterScale)
+		},
+	})
+	RegisterTool(Tool{
+		Name: "pen", Label: "Pen tool", Icon: "documentCreate", Shortcut: "p",
+		Build: func(ctx *ToolContext, d Drag) Annotation {
+			return NewPath(d.Path, ctx.Color, ctx.StrokeWidth)
+		},
+	})
+	RegisterTool(Tool{
+		Name: "ellipse", Label: "Ellipse tool", Icon: "radioButton", Shortcut: "e",
+		Constrain: ConstrainSquare,
+		Build: func(ctx *ToolContext, d Drag) Annotation {
+			return NewEllipse(image.Rectangle{Min: d.Start, Max: d.End}, ctx.Color, ctx.StrokeWidth, ctx.Filled)
+		},
+	})
+	RegisterTool(Tool{
+		Name: "line", Label: "Line tool", Icon: "contentRemove", Shortcut: "l",
+		Constrain: Constrain45,
+		Build: func(ctx *ToolContext, d Drag) Annotation {
+			return NewLine(d.Start, d.End, ctx.Color, ctx.StrokeWidth)
+		},
+	})
+	RegisterTool(Tool{
+		Name: "double_arrow", Label: "Double arrow tool", Icon: "viewFullScreen", Shortcut: "d",
+		Constrain: Constrain45,
+		Build: func(ctx *ToolContext, d Drag) Annotation {
+			return NewDoubleArrow(d.Start, d.End, ctx.Color, ctx.StrokeWidth)
+		},
+	})
+	RegisterTool(Tool{
+		Name: "curved_arrow", Label: "Curved arrow tool", Icon: "contentRedo", Shortcut: "c",
+		Constrain: Constrain45,
+		Build: func(ctx *ToolContext, d Drag) Annotation {
+			return NewCurvedArrow(d.Start, d.End, ctx.Color, ctx.StrokeWidth)
+		},
+	})
+	RegisterTool(Tool{
+		Name: "spotlight", Label: "Spotlight tool", Icon: "visibility", Shortcut: "s",
+		Options: []Option{
+			{Name: "shape", Default: "rounded", Choices: []Choice{
+				{Label: "Rounded Rectangle", Value: "rounded"},
+				{Label: "Ellipse", Value: "ellipse"},
+			}},
+			{Name: "desaturate", Label: "Desaturate", Default: false},
+			{Name: "opacity", Label: "Dim Opacity", Default: 0.6, Max: 1, Step: 0.05, Percent: true},
+		},
+		Build:   buildSpotlight,
+		Preview: previewSpotlight,
+		Finish:  finishSpotlight,
+		Restyle: restyleSpotlight,
+	})
+	RegisterTool(Tool{
+		Name: "magnifier", Label: "Magnifier tool", Icon: "viewZoomIn", Shortcut: "m",
+		Options: []Option{
+			{Name: "zoom", Default: 2.0, Min: MinMagnifierZoom, Max: MaxMagnifierZoom, Choices: []Choice{
+				{Label: "2× Zoom", Value: 2.0},
+				{Label: "3× Zoom", Value: 3.0},
+				{Label: "4× Zoom", Value: 4.0},
+			}},
+			{Name: "connector", Label: "Connector Line", Default: true},
+		},
+		Build: func(ctx *ToolContext, d Drag) Annotation {
+			if d.Rect().Empty() {
+				return nil
+			}
+			return NewMagnifier(d.Rect(), ctx.Screenshot.Bounds(), Setting[float64](ctx, "zoom"), ctx.Color, ctx.StrokeWidth, Setting[bool](ctx, "connector"))
+		},
+	})
+	RegisterTool(Tool{
+		Name: "measure", Label: "Measure tool", Icon: "grid", Shortcut: "u",
+		Options: []Option{
+			{Name: "mode", Default: "distance", Choices: []Choice{
+				{Label: "Distance", Value: "distance"},
+				{Label: "Dimensions", Value: "dimensions"},
+				{Label: "Edge to Edge", Value: "edges"},
+			}},
+		},
+		Constrain: Constrain45,
+		Build:     buildMeasure,
+	})
+}
+
+// spotlightRegion returns the region a spotlight drag adds
+func spotlightRegion(ctx *ToolContext, d Drag) SpotlightRegion {
+	scale := ctx.ScaleFactor
+	if scale <= 0 {
+		scale = 1
+	}
+	return SpotlightRegion{
+		Rect:   d.Rect(),
+		Shape:  spotlightShape(Setting[string](ctx, "shape")),
+		Radius: int(spotlightRadius * scale),
+	}
+}
+
+// spotlightShape returns the shape named by the spotlight's shape setting
+func spotlightShape(name string) SpotlightShape {
+	if name == "ellipse" {
+		return SpotlightEllipse
+	}
+	return SpotlightRoundedRect
+}
+
+// newSpotlight returns a spotlight dimmed as the settings in ctx say
+func newSpotlight(ctx *ToolContext, region SpotlightRegion) *SpotlightAnnotation {
+	return NewSpotlight(color.Black, Setting[float64](ctx, "opacity"), Setting[bool](ctx, "desaturate"), region)
+}
+
+// findSpotlight returns the image's spotlight and its index, if one has been drawn
+func findSpotlight(annotations []Annotation) (*SpotlightAnnotation, int) {
+	for i, ann := range annotations {
+		if s, ok := ann.(*SpotlightAnnotation); ok {
+			return s, i
+		}
+	}
+	return nil, -1
+}
+
+// buildSpotlight returns a new spotlight around the dragged region
+func buildSpotlight(ctx *ToolContext, d Drag) Annotation {
+	region := spotlightRegion(ctx, d)
+	if region.Rect.Empty() {
+		return nil
+	}
+	return newSpotlight(ctx, region)
+}
+
+// finishSpotlight adds the dragged region to the image's spotlight, only
+// creating a new spotlight if there isn't one yet
+func finishSpotlight(ctx *ToolContext, d Drag) Annotation {
+	existing, _ := findSpotlight(ctx.Annotations)
+	if existing == nil {
+		return buildSpotlight(ctx, d)
+	}
+	if region := spotlightRegion(ctx, d); !region.Rect.Empty() {
+		existing.AddRegion(region)
+	}
+	return nil
+}
+
+// previewSpotlight renders img as if the dragged region had been added to
+// the spotlight. The spotlight dims the screenshot beneath everything else,
+// so the whole image is re-rendered rather than drawn over.
+func previewSpotlight(img *image.RGBA, ctx *ToolContext, d Drag) {
+	annotations := slices.Clone(ctx.Annotations)
+	if existing, i := findSpotlight(annotations); existing != nil {
+		extended := *existing
+		extended.Regions = append(slices.Clone(existing.Regions), spotlightRegion(ctx, d))
+		annotations[i] = &extended
+	} else {
+		annotations = append(annotations, newSpotlight(ctx, spotlightRegion(ctx, d)))
+	}
+
+	draw.Draw(img, img.Bounds(), ctx.Screenshot, image.Point{}, draw.Src)
+	Render(img, annotations)
+}
+
+// restyleSpotlight applies the dimming settings to the image's spotlight
+func restyleSpotlight(ctx *ToolContext) {
+	if s, _ := findSpotlight(ctx.Annotations); s != nil {
+		s.DimOpacity = Setting[float64](ctx, "opacity")
+		s.Desaturate = Setting[bool](ctx, "desaturate")
+	}
+}
+
+// buildMeasure returns a measurement for the drag, or nil if there is
+// nothing to measure. Edge to edge measurements run along the axis the drag
+// mostly follows.
+func buildMeasure(ctx *ToolContext, d Drag) Annotation {
+	mode := Setting[string](ctx, "mode")
+	start, end := d.Start, d.End
+	if mode == "edges" {
+		horizontal := abs(end.X-start.X) >= abs(end.Y-start.Y)
+		start, end = DetectGap(ctx.Screenshot, start, horizontal)
+	}
+	if start == end {
+		return nil
+	}
+	kind := MeasureDistance
+	if mode == "dimensions" {
+		kind = MeasureDimensions
+	}
+	return NewMeasure(start, end, kind, ctx.Color, ctx.StrokeWidth, ctx.ScaleFactor)
+}
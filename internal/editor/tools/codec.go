@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// annotationTypes maps the type names used in saved projects to constructors
+// for an empty annotation of that type. Names are part of the file format,
+// so they must never change once released.
+var annotationTypes = map[string]func() Annotation{
+	"arrow":        func() Annotation { return &ArrowAnnotation{} },
+	"rect":         func() Annotation { return &RectAnnotation{} },
+	"highlighter":  func() Annotation { return &HighlighterAnnotation{} },
+	"path":         func() Annotation { return &PathAnnotation{} },
+	"ellipse":      func() Annotation { return &EllipseAnnotation{} },
+	"line":         func() Annotation { return &LineAnnotation{} },
+	"double_arrow": func() Annotation { return &DoubleArrowAnnotation{} },
+	"curved_arrow": func() Annotation { return &CurvedArrowAnnotation{} },
+	"spotlight":    func() Annotation { return &SpotlightAnnotation{} },
+	"magnifier":    func() Annotation { return &MagnifierAnnotation{} },
+	"measure":      func() Annotation { return &MeasureAnnotation{} },
+	"text":         func() Annotation { return &TextAnnotation{} },
+	"badge":        func() Annotation { return &BadgeAnnotation{} },
+}
+
+// annotationNames is the reverse of annotationTypes
+var annotationNames = func() map[reflect.Type]string {
+	names := make(map[reflect.Type]string, len(annotationTypes))
+	for name, newAnnotation := range annotationTypes {
+		names[reflect.TypeOf(newAnnotation())] = name
+	}
+	return names
+}()
+
+// styled is implemented by every annotation through its embedded BaseAnnotation
+type styled interface {
+	base() *BaseAnnotation
+}
+
+// base gives the codec access to the shared style of any annotation
+func (b *BaseAnnotation) base() *BaseAnnotation {
+	return b
+}
+
+// envelope is the saved form of an annotation. The colour is kept outside
+// the type-specific data because color.Color is an interface.
+type envelope struct {
+	Type  string          `json:"type"`
+	Color string          `json:"color"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// MarshalAnnotation encodes an annotation as type-tagged JSON
+func MarshalAnnotation(a Annotation) ([]byte, error) {
+	name, ok := annotationNames[reflect.TypeOf(a)]
+	if !ok {
+		return nil, fmt.Errorf("unknown annotation type %T", a)
+	}
+	s, ok := a.(styled)
+	if !ok {
+		return nil, fmt.Errorf("annotation type %T has no style", a)
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s annotation: %w", name, err)
+	}
+
+	env := envelope{Type: name, Data: data}
+	if c := s.base().Color; c != nil {
+		env.Color = HexColor(c)
+	}
+	return json.Marshal(env)
+}
+
+// UnmarshalAnnotation decodes an annotation written by MarshalAnnotation
+func UnmarshalAnnotation(data []byte) (Annotation, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to decode annotation: %w", err)
+	}
+
+	newAnnotation, ok := annotationTypes[env.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown annotation type %q", env.Type)
+	}
+
+	a := newAnnotation()
+	if len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, a); err != nil {
+			return nil, fmt.Errorf("failed to decode %s annotation: %w", env.Type, err)
+		}
+	}
+
+	if env.Color != "" {
+		c, err := ParseHexColor(env.Color)
+		if err != nil {
+			return nil, fmt.Errorf("invalid colour in %s annotation: %w", env.Type, err)
+		}
+		a.(styled).base().Color = c
+	}
+	return a, nil
+}
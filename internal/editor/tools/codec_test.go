@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sampleAnnotations returns one fully styled annotation of every saved type
+func sampleAnnotations() map[string]Annotation {
+	red := color.NRGBA{R: 255, A: 255}
+	yellow := color.NRGBA{R: 255, G: 230, A: 110}
+
+	curved := NewCurvedArrow(image.Pt(10, 10), image.Pt(90, 10), red, 3)
+	curved.MoveHandle(0, image.Pt(50, 60))
+
+	// Layer flags are saved with the style
+	line := NewLine(image.Pt(3, 4), image.Pt(50, 60), red, 1)
+	line.Hidden = true
+	line.Locked = true
+
+	return map[string]Annotation{
+		"arrow":        NewArrow(image.Pt(1, 2), image.Pt(30, 40), red, 3),
+		"rect":         NewRect(image.Rect(5, 5, 50, 40), red, 2, true),
+		"highlighter":  NewHighlighter(image.Pt(0, 10), image.Pt(80, 12), yellow, 18),
+		"path":         NewPath([]image.Point{{0, 0}, {20, 30}, {40, 0}}, red, 4),
+		"ellipse":      NewEllipse(image.Rect(10, 10, 60, 40), red, 5, false),
+		"line":         line,
+		"double_arrow": NewDoubleArrow(image.Pt(3, 4), image.Pt(50, 60), red, 2),
+		"curved_arrow": curved,
+		"spotlight": NewSpotlight(color.NRGBA{A: 255}, 0.6, true,
+			SpotlightRegion{Rect: image.Rect(0, 0, 20, 20), Radius: 4},
+			SpotlightRegion{Rect: image.Rect(30, 30, 60, 50), Shape: SpotlightEllipse},
+		),
+		"magnifier": NewMagnifier(image.Rect(10, 10, 30, 20), image.Rect(0, 0, 200, 200), 3, red, 2, true),
+		"measure":   NewMeasure(image.Pt(10, 10), image.Pt(70, 40), MeasureDimensions, red, 2, 2),
+		"text":      NewText(image.Pt(40, 20), "Click <here> & save", red, 14),
+		"badge":     NewBadge(image.Pt(20, 20), "3", red, 12),
+	}
+}
+
+func TestAnnotationRoundTrip(t *testing.T) {
+	samples := sampleAnnotations()
+	if len(samples) != len(annotationTypes) {
+		t.Fatalf("Have samples for %d annotation types, but %d are registered", len(samples), len(annotationTypes))
+	}
+
+	for name, ann := range samples {
+		t.Run(name, func(t *testing.T) {
+			data, err := MarshalAnnotation(ann)
+			if err != nil {
+				t.Fatalf("MarshalAnnotation() error = %v", err)
+			}
+			if !strings.Contains(string(data), `"type":"`+name+`"`) {
+				t.Errorf("MarshalAnnotation() = %s, want type %q", data, name)
+			}
+
+			got, err := UnmarshalAnnotation(data)
+			if err != nil {
+				t.Fatalf("UnmarshalAnnotation() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, ann) {
+				t.Errorf("Round trip = %+v, want %+v", got, ann)
+			}
+		})
+	}
+}
+
+func TestMarshalAnnotationNormalisesColour(t *testing.T) {
+	ann := NewArrow(image.Pt(0, 0), image.Pt(10, 10), color.RGBA{R: 255, A: 255}, 2)
+
+	data, err := MarshalAnnotation(ann)
+	if err != nil {
+		t.Fatalf("MarshalAnnotation() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"color":"#ff0000"`) {
+		t.Errorf("MarshalAnnotation() = %s, want colour #ff0000", data)
+	}
+}
+
+func TestUnmarshalAnnotationErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not json", `{`},
+		{"unknown type", `{"type":"sticker","data":{}}`},
+		{"bad data", `{"type":"arrow","data":{"start":"left"}}`},
+		{"bad colour", `{"type":"arrow","color":"#nothex","data":{}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := UnmarshalAnnotation([]byte(tt.data)); err == nil {
+				t.Errorf("UnmarshalAnnotation(%s) expected error", tt.data)
+			}
+		})
+	}
+}
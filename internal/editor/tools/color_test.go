@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    color.NRGBA
+		wantErr bool
+	}{
+		{"#ff0000", color.NRGBA{R: 255, A: 255}, false},
+		{"00ff00", color.NRGBA{G: 255, A: 255}, false},
+		{"#00f", color.NRGBA{B: 255, A: 255}, false},
+		{"#ffe60080", color.NRGBA{R: 255, G: 230, A: 128}, false},
+		{" #FFFFFF ", color.NRGBA{R: 255, G: 255, B: 255, A: 255}, false},
+		{"#ff00", color.NRGBA{}, true},
+		{"#gggggg", color.NRGBA{}, true},
+		{"", color.NRGBA{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseHexColor(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHexColor(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseHexColor(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHexColor(t *testing.T) {
+	tests := []struct {
+		input color.Color
+		want  string
+	}{
+		{color.RGBA{R: 255, A: 255}, "#ff0000"},
+		{color.NRGBA{R: 255, G: 230, A: 110}, "#ffe6006e"},
+		{color.Black, "#000000"},
+	}
+
+	for _, tt := range tests {
+		if got := HexColor(tt.input); got != tt.want {
+			t.Errorf("HexColor(%v) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestHexColorRoundTrip(t *testing.T) {
+	c := color.NRGBA{R: 12, G: 34, B: 56, A: 78}
+	parsed, err := ParseHexColor(HexColor(c))
+	if err != nil {
+		t.Fatalf("ParseHexColor() error = %v", err)
+	}
+	if parsed != c {
+		t.Errorf("Round trip = %v, want %v", parsed, c)
+	}
+}
@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// labelFont is the typeface used for text drawn onto the image
+var labelFont = sync.OnceValue(func() *opentype.Font {
+	f, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse embedded label font: %v", err))
+	}
+	return f
+})
+
+// label is a line of text centred on a point, drawn over a rounded pill so
+// that it stays legible on any background
+type label struct {
+	text   string
+	centre fpoint
+	size   float64 // Text height in pixels
+	circle bool    // Draw the background as a circle rather than a pill
+}
+
+// face returns the label's font at its size
+func (l label) face() font.Face {
+	face, err := opentype.NewFace(labelFont(), &opentype.FaceOptions{Size: l.size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		panic(fmt.Sprintf("failed to create label font face: %v", err))
+	}
+	return face
+}
+
+// pill returns the corners of the label's background
+func (l label) pill() (lo, hi fpoint) {
+	face := l.face()
+	defer face.Close()
+
+	width := float64(font.MeasureString(face, l.text)) / 64
+	padX, padY := l.size*0.5, l.size*0.3
+	halfW, halfH := width/2+padX, l.size/2+padY
+	if l.circle {
+		halfW = max(halfW, halfH)
+		halfH = halfW
+	}
+	return fpoint{l.centre.X - halfW, l.centre.Y - halfH}, fpoint{l.centre.X + halfW, l.centre.Y + halfH}
+}
+
+// bounds returns the pixels the label covers
+func (l label) bounds() image.Rectangle {
+	lo, hi := l.pill()
+	return image.Rect(int(math.Floor(lo.X)), int(math.Floor(lo.Y)), int(math.Ceil(hi.X)), int(math.Ceil(hi.Y)))
+}
+
+// draw renders the label in fg over a pill of bg
+func (l label) draw(img *image.RGBA, bg, fg color.Color) {
+	lo, hi := l.pill()
+	s := newShape(img, l.bounds())
+	s.roundedRect(lo, hi, (hi.Y-lo.Y)/2)
+	s.draw(img, bg)
+
+	face := l.face()
+	defer face.Close()
+
+	width := font.MeasureString(face, l.text)
+	metrics := face.Metrics()
+	// Centre the cap height on the label's centre
+	baseline := l.centre.Y + float64(metrics.CapHeight)/64/2
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(fg),
+		Face: face,
+		Dot:  fixed.Point26_6{X: fixed.Int26_6(l.centre.X*64) - width/2, Y: fixed.Int26_6(baseline * 64)},
+	}
+	d.DrawString(l.text)
+}
+
+// svg returns the label as a rounded rectangle and centred text
+func (l label) svg(bg, fg color.Color) string {
+	lo, hi := l.pill()
+	radius := svgNum((hi.Y - lo.Y) / 2)
+	return svgRect(lo, hi, fmt.Sprintf(`rx="%s" %s`, radius, svgPaint("fill", bg))) +
+		fmt.Sprintf(`<text x="%s" y="%s" font-family="Go, Helvetica, Arial, sans-serif" font-weight="bold" font-size="%s" text-anchor="middle" dominant-baseline="central" %s>%s</text>`,
+			svgNum(l.centre.X), svgNum(l.centre.Y), svgNum(l.size), svgPaint("fill", fg), html.EscapeString(l.text))
+}
+
+// contrastColor returns black or white, whichever reads better on c
+func contrastColor(c color.Color) color.Color {
+	r, g, b, _ := c.RGBA()
+	luma := (299*r + 587*g + 114*b) / 1000
+	if luma > 0x8000 {
+		return color.Black
+	}
+	return color.White
+}
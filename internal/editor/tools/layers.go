@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"image/color"
+	"reflect"
+)
+
+// TypeName returns the name an annotation's type is saved under, such as
+// "arrow" or "double_arrow"
+func TypeName(a Annotation) string {
+	return annotationNames[reflect.TypeOf(a)]
+}
+
+// ColorOf returns an annotation's colour
+func ColorOf(a Annotation) color.Color {
+	if s, ok := a.(styled); ok {
+		return s.base().Color
+	}
+	return nil
+}
+
+// IsHidden reports whether an annotation is hidden
+func IsHidden(a Annotation) bool {
+	s, ok := a.(styled)
+	return ok && s.base().Hidden
+}
+
+// SetHidden shows or hides an annotation
+func SetHidden(a Annotation, hidden bool) {
+	if s, ok := a.(styled); ok {
+		s.base().Hidden = hidden
+	}
+}
+
+// IsLocked reports whether an annotation is locked
+func IsLocked(a Annotation) bool {
+	s, ok := a.(styled)
+	return ok && s.base().Locked
+}
+
+// SetLocked locks or unlocks an annotation
+func SetLocked(a Annotation, locked bool) {
+	if s, ok := a.(styled); ok {
+		s.base().Locked = locked
+	}
+}
+
+// The layer ordering functions reorder annotations in place and report
+// whether a moved. Underlays such as spotlights are always drawn beneath
+// everything else, so an annotation only moves past others of its own kind.
+
+// BringForward moves a one step up the draw order
+func BringForward(annotations []Annotation, a Annotation) bool {
+	i := indexOf(annotations, a)
+	if i < 0 {
+		return false
+	}
+	for j := i + 1; j < len(annotations); j++ {
+		if sameLayer(annotations[j], a) {
+			annotations[i], annotations[j] = annotations[j], annotations[i]
+			return true
+		}
+	}
+	return false
+}
+
+// SendBackward moves a one step down the draw order
+func SendBackward(annotations []Annotation, a Annotation) bool {
+	i := indexOf(annotations, a)
+	if i < 0 {
+		return false
+	}
+	for j := i - 1; j >= 0; j-- {
+		if sameLayer(annotations[j], a) {
+			annotations[i], annotations[j] = annotations[j], annotations[i]
+			return true
+		}
+	}
+	return false
+}
+
+// BringToFront moves a to the top of the draw order
+func BringToFront(annotations []Annotation, a Annotation) bool {
+	moved := false
+	for BringForward(annotations, a) {
+		moved = true
+	}
+	return moved
+}
+
+// SendToBack moves a to the bottom of the draw order
+func SendToBack(annotations []Annotation, a Annotation) bool {
+	moved := false
+	for SendBackward(annotations, a) {
+		moved = true
+	}
+	return moved
+}
+
+// indexOf returns the position of a in annotations, or -1
+func indexOf(annotations []Annotation, a Annotation) int {
+	for i, ann := range annotations {
+		if ann == a {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameLayer reports whether a and b are both underlays or both not
+func sameLayer(a, b Annotation) bool {
+	_, aUnder := a.(underlay)
+	_, bUnder := b.(underlay)
+	return aUnder == bUnder
+}
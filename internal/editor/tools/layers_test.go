@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+func TestLayerOrdering(t *testing.T) {
+	a := NewRect(image.Rect(0, 0, 10, 10), color.Black, 1, false)
+	b := NewLine(image.Pt(0, 0), image.Pt(10, 10), color.Black, 1)
+	c := NewEllipse(image.Rect(0, 0, 10, 10), color.Black, 1, false)
+	spot := NewSpotlight(color.Black, 0.5, false)
+
+	tests := []struct {
+		name      string
+		move      func([]Annotation, Annotation) bool
+		target    Annotation
+		want      []Annotation
+		wantMoved bool
+	}{
+		{"bring forward", BringForward, a, []Annotation{spot, b, a, c}, true},
+		{"bring forward skips underlays", BringForward, spot, []Annotation{spot, a, b, c}, false},
+		{"bring forward at top", BringForward, c, []Annotation{spot, a, b, c}, false},
+		{"send backward", SendBackward, c, []Annotation{spot, a, c, b}, true},
+		{"send backward at bottom", SendBackward, a, []Annotation{spot, a, b, c}, false},
+		{"bring to front", BringToFront, a, []Annotation{spot, b, c, a}, true},
+		{"send to back", SendToBack, c, []Annotation{spot, c, a, b}, true},
+		{"unknown annotation", BringForward, NewRect(image.Rect(0, 0, 1, 1), color.Black, 1, false), []Annotation{spot, a, b, c}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anns := []Annotation{spot, a, b, c}
+			if moved := tt.move(anns, tt.target); moved != tt.wantMoved {
+				t.Errorf("moved = %v, want %v", moved, tt.wantMoved)
+			}
+			if !slices.Equal(anns, tt.want) {
+				t.Errorf("order = %v, want %v", anns, tt.want)
+			}
+		})
+	}
+}
+
+func TestHiddenAndLocked(t *testing.T) {
+	back := NewRect(image.Rect(0, 0, 20, 20), color.NRGBA{B: 255, A: 255}, 1, true)
+	front := NewRect(image.Rect(0, 0, 20, 20), color.NRGBA{R: 255, A: 255}, 1, true)
+	anns := []Annotation{back, front}
+
+	if got := AnnotationAt(anns, 5, 5); got != Annotation(front) {
+		t.Fatalf("AnnotationAt() = %v, want front", got)
+	}
+
+	SetLocked(front, true)
+	if !IsLocked(front) {
+		t.Error("IsLocked() = false after SetLocked(true)")
+	}
+	if got := AnnotationAt(anns, 5, 5); got != Annotation(back) {
+		t.Errorf("AnnotationAt() with front locked = %v, want back", got)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	SetHidden(front, true)
+	Render(img, anns)
+	if got := img.RGBAAt(5, 5); got.B != 255 || got.R != 0 {
+		t.Errorf("pixel with front hidden = %v, want back's blue", got)
+	}
+
+	SetHidden(back, true)
+	if got := AnnotationAt(anns, 5, 5); got != nil {
+		t.Errorf("AnnotationAt() with everything hidden or locked = %v, want nil", got)
+	}
+}
+
+func TestTypeNameAndColor(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	a := NewDoubleArrow(image.Pt(0, 0), image.Pt(5, 5), red, 2)
+	if got := TypeName(a); got != "double_arrow" {
+		t.Errorf("TypeName() = %q, want %q", got, "double_arrow")
+	}
+	if got := ColorOf(a); got != color.Color(red) {
+		t.Errorf("ColorOf() = %v, want %v", got, red)
+	}
+}
@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Zoom limits for magnifier insets
+const (
+	MinMagnifierZoom = 2.0
+	MaxMagnifierZoom = 4.0
+)
+
+// magnifierGap is how far, in pixels, a new inset is placed from its source
+const magnifierGap = 16
+
+// MagnifierAnnotation shows an enlarged copy of part of the image elsewhere
+// on the canvas. The inset samples the image every time it is drawn, so it
+// follows the source region when that is moved.
+type MagnifierAnnotation struct {
+	BaseAnnotation
+	Source    image.Rectangle `json:"source"`
+	Centre    image.Point     `json:"centre"` // Centre of the enlarged inset
+	Zoom      float64         `json:"zoom"`
+	Connector bool            `json:"connector"` // Draws a line from the source region to the inset
+}
+
+// NewMagnifier creates a magnifier for source, placing its inset beside the
+// source wherever there is room within bounds
+func NewMagnifier(source, bounds image.Rectangle, zoom float64, c color.Color, strokeWidth int, connector bool) *MagnifierAnnotation {
+	m := &MagnifierAnnotation{
+		BaseAnnotation: BaseAnnotation{Color: c, StrokeWidth: strokeWidth},
+		Source:         source.Canon(),
+		Zoom:           math.Max(MinMagnifierZoom, math.Min(MaxMagnifierZoom, zoom)),
+		Connector:      connector,
+	}
+	m.Centre = m.placeInset(bounds)
+	return m
+}
+
+// Inset returns the rectangle the enlarged copy is drawn into
+func (m *MagnifierAnnotation) Inset() image.Rectangle {
+	w := int(math.Round(float64(m.Source.Dx()) * m.Zoom))
+	h := int(math.Round(float64(m.Source.Dy()) * m.Zoom))
+	min := m.Centre.Sub(image.Pt(w/2, h/2))
+	return image.Rectangle{Min: min, Max: min.Add(image.Pt(w, h))}
+}
+
+// Draw renders the enlarged inset, its border, an outline around the source
+// region and, optionally, the connector between them
+func (m *MagnifierAnnotation) Draw(img *image.RGBA) {
+	inset := m.Inset()
+	if m.Source.Empty() || inset.Empty() {
+		return
+	}
+
+	// Scale into a scratch image first, since the inset may overlap its source
+	zoomed := image.NewRGBA(image.Rect(0, 0, inset.Dx(), inset.Dy()))
+	xdraw.CatmullRom.Scale(zoomed, zoomed.Bounds(), img, m.Source, xdraw.Src, nil)
+	xdraw.Draw(img, inset, zoomed, image.Point{}, xdraw.Src)
+
+	sw := float64(m.StrokeWidth)
+	s := newShape(img, m.Bounds())
+	strokeRect(s, inset, sw)
+	strokeRect(s, m.Source, math.Max(1, sw/2))
+	if m.Connector {
+		from, to := rectCentre(m.Source), rectCentre(inset)
+		if !inset.Overlaps(m.Source) {
+			s.segment(rectExit(m.Source, from, to), rectExit(inset, to, from), math.Max(1, sw/2), false)
+		}
+	}
+	s.draw(img, m.Color)
+}
+
+// Bounds returns the box around the source region and the inset
+func (m *MagnifierAnnotation) Bounds() image.Rectangle {
+	return m.Source.Union(m.Inset()).Inset(-m.StrokeWidth)
+}
+
+// Contains returns true if the point is within the source region or the inset
+func (m *MagnifierAnnotation) Contains(x, y int) bool {
+	p := image.Pt(x, y)
+	return p.In(m.Source) || p.In(m.Inset())
+}
+
+// Transform moves the magnifier to follow a change to the image geometry.
+// The zoom is kept, so the inset scales along with its source.
+func (m *MagnifierAnnotation) Transform(t Transform) {
+	m.Source = t.ApplyRect(m.Source)
+	m.Centre = t.Apply(m.Centre)
+	m.transformStroke(t)
+}
+
+// SVG returns the magnifier as a clipped, scaled copy of the screenshot
+// with its border, source outline and connector. Unlike Draw, the inset
+// shows only the screenshot, not other annotations over the source.
+func (m *MagnifierAnnotation) SVG(id string) string {
+	inset := m.Inset()
+	if m.Source.Empty() || inset.Empty() {
+		return ""
+	}
+
+	sw := float64(m.StrokeWidth)
+	thin := math.Max(1, sw/2)
+	outline := func(width float64) string {
+		return fmt.Sprintf(`fill="none" %s stroke-width="%s" stroke-linejoin="miter"`, svgPaint("stroke", m.Color), svgNum(width))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<g><defs><clipPath id="%s-clip"><rect x="%d" y="%d" width="%d" height="%d"/></clipPath></defs>`,
+		id, inset.Min.X, inset.Min.Y, inset.Dx(), inset.Dy())
+	fmt.Fprintf(&b, `<g clip-path="url(#%s-clip)"><use href="#%s" transform="translate(%s %s) scale(%s %s)"/></g>`,
+		id, ScreenshotID,
+		svgNum(float64(inset.Min.X)-float64(m.Source.Min.X)*float64(inset.Dx())/float64(m.Source.Dx())),
+		svgNum(float64(inset.Min.Y)-float64(m.Source.Min.Y)*float64(inset.Dy())/float64(m.Source.Dy())),
+		svgNum(float64(inset.Dx())/float64(m.Source.Dx())),
+		svgNum(float64(inset.Dy())/float64(m.Source.Dy())))
+	b.WriteString(svgRect(pt(inset.Min), pt(inset.Max), outline(sw)))
+	b.WriteString(svgRect(pt(m.Source.Min), pt(m.Source.Max), outline(thin)))
+	if m.Connector && !inset.Overlaps(m.Source) {
+		from, to := rectCentre(m.Source), rectCentre(inset)
+		b.WriteString(svgLine(rectExit(m.Source, from, to), rectExit(inset, to, from), m.Color, thin, "butt"))
+	}
+	b.WriteString("</g>")
+	return b.String()
+}
+
+// Handles returns the centres of the source region and the inset
+func (m *MagnifierAnnotation) Handles() []image.Point {
+	return []image.Point{rectCentre(m.Source).point(), m.Centre}
+}
+
+// MoveHandle moves the source region (handle 0) or the inset (handle 1)
+func (m *MagnifierAnnotation) MoveHandle(i int, p image.Point) {
+	switch i {
+	case 0:
+		m.Source = m.Source.Add(p.Sub(rectCentre(m.Source).point()))
+	case 1:
+		m.Centre = p
+	}
+}
+
+// placeInset returns a centre for the inset beside the source, trying the
+// right, left, below and above in turn, or the middle of bounds if the inset
+// fits nowhere beside it
+func (m *MagnifierAnnotation) placeInset(bounds image.Rectangle) image.Point {
+	w := int(math.Round(float64(m.Source.Dx()) * m.Zoom))
+	h := int(math.Round(float64(m.Source.Dy()) * m.Zoom))
+	src := m.Source
+	midX := (src.Min.X + src.Max.X) / 2
+	midY := (src.Min.Y + src.Max.Y) / 2
+
+	candidates := []image.Point{
+		{src.Max.X + magnifierGap + w/2, midY},
+		{src.Min.X - magnifierGap - w + w/2, midY},
+		{midX, src.Max.Y + magnifierGap + h/2},
+		{midX, src.Min.Y - magnifierGap - h + h/2},
+	}
+	for _, c := range candidates {
+		// Slide along the side if needed to keep the inset inside the image
+		c.X = max(bounds.Min.X+w/2, min(c.X, bounds.Max.X-w+w/2))
+		c.Y = max(bounds.Min.Y+h/2, min(c.Y, bounds.Max.Y-h+h/2))
+		inset := image.Rect(c.X-w/2, c.Y-h/2, c.X-w/2+w, c.Y-h/2+h)
+		if inset.In(bounds) && !inset.Overlaps(src) {
+			return c
+		}
+	}
+	return image.Pt((bounds.Min.X+bounds.Max.X)/2, (bounds.Min.Y+bounds.Max.Y)/2)
+}
+
+// rectCentre returns the centre of r
+func rectCentre(r image.Rectangle) fpoint {
+	return fpoint{X: float64(r.Min.X+r.Max.X) / 2, Y: float64(r.Min.Y+r.Max.Y) / 2}
+}
+
+// point rounds p to the nearest integer image point
+func (p fpoint) point() image.Point {
+	return image.Pt(int(math.Round(p.X)), int(math.Round(p.Y)))
+}
+
+// rectExit returns where the line from `from`, inside r, towards `to` crosses
+// the edge of r
+func rectExit(r image.Rectangle, from, to fpoint) fpoint {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	t := math.Inf(1)
+	if dx > 0 {
+		t = math.Min(t, (float64(r.Max.X)-from.X)/dx)
+	} else if dx < 0 {
+		t = math.Min(t, (float64(r.Min.X)-from.X)/dx)
+	}
+	if dy > 0 {
+		t = math.Min(t, (float64(r.Max.Y)-from.Y)/dy)
+	} else if dy < 0 {
+		t = math.Min(t, (float64(r.Min.Y)-from.Y)/dy)
+	}
+	if math.IsInf(t, 1) {
+		return from
+	}
+	return fpoint{X: from.X + dx*t, Y: from.Y + dy*t}
+}
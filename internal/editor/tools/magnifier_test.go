@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestMagnifierInsetSize(t *testing.T) {
+	m := NewMagnifier(image.Rect(10, 10, 30, 20), image.Rect(0, 0, 200, 200), 3, color.Black, 2, true)
+
+	inset := m.Inset()
+	if inset.Dx() != 60 || inset.Dy() != 30 {
+		t.Errorf("Inset() size = %dx%d, want 60x30", inset.Dx(), inset.Dy())
+	}
+	if !inset.In(image.Rect(0, 0, 200, 200)) {
+		t.Errorf("Inset() = %v, want inside the image", inset)
+	}
+	if inset.Overlaps(m.Source) {
+		t.Errorf("Inset() = %v overlaps source %v", inset, m.Source)
+	}
+}
+
+func TestMagnifierZoomIsClamped(t *testing.T) {
+	bounds := image.Rect(0, 0, 200, 200)
+	if m := NewMagnifier(image.Rect(0, 0, 10, 10), bounds, 1, color.Black, 2, false); m.Zoom != MinMagnifierZoom {
+		t.Errorf("Zoom = %v, want %v", m.Zoom, MinMagnifierZoom)
+	}
+	if m := NewMagnifier(image.Rect(0, 0, 10, 10), bounds, 10, color.Black, 2, false); m.Zoom != MaxMagnifierZoom {
+		t.Errorf("Zoom = %v, want %v", m.Zoom, MaxMagnifierZoom)
+	}
+}
+
+func TestMagnifierPlacesInsetWhereThereIsRoom(t *testing.T) {
+	bounds := image.Rect(0, 0, 200, 100)
+
+	// No room to the right of a source at the right edge, so it goes left
+	m := NewMagnifier(image.Rect(170, 40, 190, 60), bounds, 2, color.Black, 2, false)
+	if inset := m.Inset(); inset.Max.X > m.Source.Min.X || !inset.In(bounds) {
+		t.Errorf("Inset() = %v, want inside %v and left of source %v", inset, bounds, m.Source)
+	}
+}
+
+func TestMagnifierDrawEnlargesSource(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	red := color.RGBA{R: 255, A: 255}
+	for y := 20; y < 40; y++ {
+		for x := 20; x < 40; x++ {
+			img.SetRGBA(x, y, red)
+		}
+	}
+
+	m := NewMagnifier(image.Rect(20, 20, 40, 40), img.Bounds(), 2, color.Black, 2, true)
+	m.Draw(img)
+
+	inset := m.Inset()
+	centre := image.Pt((inset.Min.X+inset.Max.X)/2, (inset.Min.Y+inset.Max.Y)/2)
+	if got := img.RGBAAt(centre.X, centre.Y); got != red {
+		t.Errorf("Inset centre = %v, want %v", got, red)
+	}
+	if got := img.RGBAAt(inset.Min.X, centre.Y); got.R != 0 {
+		t.Errorf("Inset border = %v, want black", got)
+	}
+}
+
+func TestMagnifierFollowsMovedSource(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	blue := color.RGBA{B: 255, A: 255}
+	for y := 120; y < 140; y++ {
+		for x := 120; x < 140; x++ {
+			img.SetRGBA(x, y, blue)
+		}
+	}
+
+	m := NewMagnifier(image.Rect(20, 20, 40, 40), img.Bounds(), 2, color.Black, 2, false)
+	m.MoveHandle(0, image.Pt(130, 130))
+	if m.Source != image.Rect(120, 120, 140, 140) {
+		t.Fatalf("Source = %v, want (120,120)-(140,140)", m.Source)
+	}
+
+	m.Draw(img)
+	if got := img.RGBAAt(m.Centre.X, m.Centre.Y); got != blue {
+		t.Errorf("Inset centre = %v, want the moved source's %v", got, blue)
+	}
+}
+
+func TestMagnifierHandles(t *testing.T) {
+	m := NewMagnifier(image.Rect(20, 20, 40, 40), image.Rect(0, 0, 200, 200), 2, color.Black, 2, false)
+	m.MoveHandle(1, image.Pt(150, 150))
+
+	handles := m.Handles()
+	if len(handles) != 2 || handles[0] != image.Pt(30, 30) || handles[1] != image.Pt(150, 150) {
+		t.Errorf("Handles() = %v, want [(30,30) (150,150)]", handles)
+	}
+	if !m.Contains(150, 150) || !m.Contains(25, 25) || m.Contains(100, 20) {
+		t.Error("Contains() should cover the source and inset only")
+	}
+}
+
+func TestMagnifierTransform(t *testing.T) {
+	m := NewMagnifier(image.Rect(20, 20, 40, 40), image.Rect(0, 0, 200, 200), 2, color.Black, 2, false)
+	m.Centre = image.Pt(100, 100)
+	m.Transform(Translate(-10, -10))
+
+	if m.Source != image.Rect(10, 10, 30, 30) || m.Centre != image.Pt(90, 90) {
+		t.Errorf("Transform() = %v / %v, want (10,10)-(30,30) / (90,90)", m.Source, m.Centre)
+	}
+}
@@ -0,0 +1,231 @@
+package tools
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// MeasureMode selects what a measurement shows
+type MeasureMode int
+
+const (
+	// MeasureDistance shows the length of the line from Start to End
+	MeasureDistance MeasureMode = iota
+	// MeasureDimensions shows the width and height of the rectangle
+	// spanned by Start and End
+	MeasureDimensions
+)
+
+// measureLabelSize is the text height of measurement labels in logical points
+const measureLabelSize = 12
+
+// edgeTolerance is how far, per colour channel, a pixel may differ from
+// the starting pixel before DetectGap treats it as an edge
+const edgeTolerance = 32
+
+// MeasureAnnotation is a ruler that labels a distance or the dimensions of
+// a rectangle in both physical pixels and logical points
+type MeasureAnnotation struct {
+	BaseAnnotation
+	Start image.Point `json:"start"`
+	End   image.Point `json:"end"`
+	Mode  MeasureMode `json:"mode"`
+
+	// ScaleFactor is the number of physical pixels per logical point
+	ScaleFactor float64 `json:"scale_factor"`
+}
+
+// NewMeasure creates a new measurement annotation
+func NewMeasure(start, end image.Point, mode MeasureMode, c color.Color, strokeWidth int, scaleFactor float64) *MeasureAnnotation {
+	return &MeasureAnnotation{
+		BaseAnnotation: BaseAnnotation{Color: c, StrokeWidth: strokeWidth},
+		Start:          start,
+		End:            end,
+		Mode:           mode,
+		ScaleFactor:    scaleFactor,
+	}
+}
+
+// scale returns the scale factor, treating unset values as 1
+func (m *MeasureAnnotation) scale() float64 {
+	if m.ScaleFactor <= 0 {
+		return 1
+	}
+	return m.ScaleFactor
+}
+
+// Text returns the measurement as shown in the label, such as
+// "240 px · 120 pt" or "200 × 100 px · 100 × 50 pt"
+func (m *MeasureAnnotation) Text() string {
+	dx, dy := float64(abs(m.End.X-m.Start.X)), float64(abs(m.End.Y-m.Start.Y))
+	if m.Mode == MeasureDimensions {
+		return fmt.Sprintf("%s × %s px · %s × %s pt",
+			formatLength(dx), formatLength(dy), formatLength(dx/m.scale()), formatLength(dy/m.scale()))
+	}
+	length := math.Hypot(dx, dy)
+	return fmt.Sprintf("%s px · %s pt", formatLength(length), formatLength(length/m.scale()))
+}
+
+// formatLength formats a length with at most one decimal place
+func formatLength(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
+
+// label returns the measurement's label, placed clear of the ruler: above
+// a mostly horizontal line, beside a mostly vertical one, or below a
+// rectangle
+func (m *MeasureAnnotation) label() label {
+	a, b := pt(m.Start), pt(m.End)
+	l := label{
+		text:   m.Text(),
+		centre: fpoint{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2},
+		size:   measureLabelSize * m.scale(),
+	}
+
+	lo, hi := l.pill()
+	halfW, halfH := (hi.X-lo.X)/2, (hi.Y-lo.Y)/2
+	gap := m.tickLength()/2 + float64(m.StrokeWidth)
+	switch {
+	case m.Mode == MeasureDimensions:
+		l.centre.Y = math.Max(a.Y, b.Y) + halfH + gap
+	case math.Abs(b.X-a.X) >= math.Abs(b.Y-a.Y):
+		l.centre.Y -= halfH + gap
+	default:
+		l.centre.X += halfW + gap
+	}
+	return l
+}
+
+// tickLength returns the length of the end marks on a distance
+func (m *MeasureAnnotation) tickLength() float64 {
+	return math.Max(6*m.scale(), float64(m.StrokeWidth)*3)
+}
+
+// geometry returns the strokes of the ruler: the line and its end marks, or
+// the outline of the rectangle
+func (m *MeasureAnnotation) geometry() [][]fpoint {
+	a, b := pt(m.Start), pt(m.End)
+	if m.Mode == MeasureDimensions {
+		return [][]fpoint{{a, {b.X, a.Y}, b, {a.X, b.Y}, a}}
+	}
+
+	strokes := [][]fpoint{{a, b}}
+	length := math.Hypot(b.X-a.X, b.Y-a.Y)
+	if length == 0 {
+		return strokes
+	}
+	// Perpendicular unit vector, scaled to half a tick
+	half := m.tickLength() / 2
+	nx, ny := -(b.Y-a.Y)/length*half, (b.X-a.X)/length*half
+	for _, p := range []fpoint{a, b} {
+		strokes = append(strokes, []fpoint{{p.X - nx, p.Y - ny}, {p.X + nx, p.Y + ny}})
+	}
+	return strokes
+}
+
+// Draw renders the ruler and its label onto the image
+func (m *MeasureAnnotation) Draw(img *image.RGBA) {
+	s := newShape(img, m.Bounds())
+	for _, stroke := range m.geometry() {
+		s.polyline(stroke, float64(m.StrokeWidth))
+	}
+	s.draw(img, m.Color)
+
+	m.label().draw(img, m.Color, contrastColor(m.Color))
+}
+
+// Bounds returns the bounding box of the ruler and its label
+func (m *MeasureAnnotation) Bounds() image.Rectangle {
+	pad := int(math.Ceil(m.tickLength()/2)) + m.StrokeWidth
+	r := image.Rectangle{Min: m.Start, Max: m.End}.Canon().Inset(-pad)
+	return r.Union(m.label().bounds())
+}
+
+// Contains returns true if the point is on the ruler or its label
+func (m *MeasureAnnotation) Contains(x, y int) bool {
+	p := image.Pt(x, y)
+	if p.In(m.label().bounds()) {
+		return true
+	}
+	tolerance := float64(m.StrokeWidth)/2 + hitSlop
+	if m.Mode == MeasureDimensions {
+		r := image.Rectangle{Min: m.Start, Max: m.End}.Canon()
+		return p.In(r.Inset(-int(tolerance)))
+	}
+	return distanceToSegment(p, m.Start, m.End) <= tolerance
+}
+
+// Transform moves the ruler to follow a change to the image geometry
+func (m *MeasureAnnotation) Transform(t Transform) {
+	m.Start, m.End = t.Apply(m.Start), t.Apply(m.End)
+	m.transformStroke(t)
+}
+
+// Handles returns the two ends of the ruler
+func (m *MeasureAnnotation) Handles() []image.Point {
+	return []image.Point{m.Start, m.End}
+}
+
+// MoveHandle moves one end of the ruler
+func (m *MeasureAnnotation) MoveHandle(i int, p image.Point) {
+	switch i {
+	case 0:
+		m.Start = p
+	case 1:
+		m.End = p
+	}
+}
+
+// SVG returns the ruler as strokes and a text label
+func (m *MeasureAnnotation) SVG(id string) string {
+	svg := "<g>"
+	for _, stroke := range m.geometry() {
+		if len(stroke) == 2 {
+			svg += svgLine(stroke[0], stroke[1], m.Color, float64(m.StrokeWidth), "butt")
+		} else {
+			svg += fmt.Sprintf(`<polyline points="%s" %s/>`, svgPoints(stroke), svgStroke(m.Color, float64(m.StrokeWidth), "square"))
+		}
+	}
+	return svg + m.label().svg(m.Color, contrastColor(m.Color)) + "</g>"
+}
+
+// DetectGap finds the run of similarly coloured pixels through p along one
+// axis, as when measuring the space between two edges. It returns the
+// points just inside each edge, with end one pixel past the last similar
+// pixel so that the distance between them is the width of the gap.
+func DetectGap(img image.Image, p image.Point, horizontal bool) (start, end image.Point) {
+	b := img.Bounds()
+	if !p.In(b) {
+		return p, p
+	}
+
+	step := image.Pt(0, 1)
+	if horizontal {
+		step = image.Pt(1, 0)
+	}
+	ref := img.At(p.X, p.Y)
+
+	start = p
+	for next := start.Sub(step); next.In(b) && similarColor(img.At(next.X, next.Y), ref); next = next.Sub(step) {
+		start = next
+	}
+	end = p
+	for next := end.Add(step); next.In(b) && similarColor(img.At(next.X, next.Y), ref); next = next.Add(step) {
+		end = next
+	}
+	return start, end.Add(step)
+}
+
+// similarColor reports whether every channel of a and b is within
+// edgeTolerance of each other
+func similarColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	within := func(x, y uint32) bool {
+		return abs(int(x>>8)-int(y>>8)) <= edgeTolerance
+	}
+	return within(ar, br) && within(ag, bg) && within(ab, bb) && within(aa, ba)
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestMeasureText(t *testing.T) {
+	tests := []struct {
+		name  string
+		start image.Point
+		end   image.Point
+		mode  MeasureMode
+		scale float64
+		want  string
+	}{
+		{"horizontal distance", image.Pt(10, 10), image.Pt(250, 10), MeasureDistance, 2, "240 px · 120 pt"},
+		{"diagonal distance", image.Pt(0, 0), image.Pt(30, 40), MeasureDistance, 1, "50 px · 50 pt"},
+		{"fractional points", image.Pt(0, 0), image.Pt(0, 25), MeasureDistance, 2, "25 px · 12.5 pt"},
+		{"dimensions", image.Pt(100, 80), image.Pt(0, 0), MeasureDimensions, 2, "100 × 80 px · 50 × 40 pt"},
+		{"unset scale", image.Pt(0, 0), image.Pt(10, 0), MeasureDistance, 0, "10 px · 10 pt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMeasure(tt.start, tt.end, tt.mode, color.Black, 2, tt.scale)
+			if got := m.Text(); got != tt.want {
+				t.Errorf("Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeasureBoundsIncludeLabel(t *testing.T) {
+	m := NewMeasure(image.Pt(50, 100), image.Pt(150, 100), MeasureDistance, color.Black, 2, 2)
+	label := m.label().bounds()
+
+	if !label.In(m.Bounds()) {
+		t.Errorf("Bounds() = %v, want it to include the label %v", m.Bounds(), label)
+	}
+	if label.Max.Y > 100 {
+		t.Errorf("label %v should sit above a horizontal ruler", label)
+	}
+	c := label.Min.Add(label.Size().Div(2))
+	if !m.Contains(c.X, c.Y) {
+		t.Error("Contains() should be true on the label")
+	}
+	if m.Contains(100, 140) {
+		t.Error("Contains() should be false away from the ruler")
+	}
+}
+
+func TestMeasureHandles(t *testing.T) {
+	m := NewMeasure(image.Pt(0, 0), image.Pt(10, 0), MeasureDistance, color.Black, 2, 1)
+	m.MoveHandle(1, image.Pt(20, 0))
+
+	if got := m.Handles(); got[0] != image.Pt(0, 0) || got[1] != image.Pt(20, 0) {
+		t.Errorf("Handles() = %v, want [(0,0) (20,0)]", got)
+	}
+	if got := m.Text(); got != "20 px · 20 pt" {
+		t.Errorf("Text() after MoveHandle = %q", got)
+	}
+}
+
+func TestDetectGap(t *testing.T) {
+	// Two dark bars with a 30 pixel white gap between them, and a slightly
+	// off-white pixel in the gap that should not count as an edge
+	img := image.NewRGBA(image.Rect(0, 0, 100, 60))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	dark := image.NewUniform(color.RGBA{R: 20, G: 20, B: 20, A: 255})
+	draw.Draw(img, image.Rect(10, 0, 20, 60), dark, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(50, 0, 60, 60), dark, image.Point{}, draw.Src)
+	img.Set(35, 30, color.RGBA{R: 240, G: 240, B: 240, A: 255})
+
+	start, end := DetectGap(img, image.Pt(30, 30), true)
+	if start != image.Pt(20, 30) || end != image.Pt(50, 30) {
+		t.Errorf("DetectGap(horizontal) = %v, %v, want (20,30), (50,30)", start, end)
+	}
+
+	// Vertically there are no edges, so the gap runs to the image bounds
+	start, end = DetectGap(img, image.Pt(30, 30), false)
+	if start != image.Pt(30, 0) || end != image.Pt(30, 60) {
+		t.Errorf("DetectGap(vertical) = %v, %v, want (30,0), (30,60)", start, end)
+	}
+
+	start, end = DetectGap(img, image.Pt(200, 30), true)
+	if start != end {
+		t.Errorf("DetectGap(outside) = %v, %v, want an empty gap", start, end)
+	}
+}
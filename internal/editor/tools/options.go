@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Settings holds a tool's options by name. Values are strings, float64s or
+// bools, so they survive a round trip through the JSON config.
+type Settings map[string]any
+
+// Option is a setting a tool offers in its toolbar menu. The type of its
+// default decides how it's chosen: a bool is toggled, a string is picked
+// from its choices, and a number is picked from its choices or, if it has
+// none, set with a slider.
+type Option struct {
+	Name    string // Key of the setting, e.g. "shape"
+	Label   string // Shown in the menu, e.g. "Dim Opacity"
+	Default any
+
+	Choices  []Choice
+	Min, Max float64 // Range of a number
+	Step     float64 // Slider increment
+	Percent  bool    // Show a number from 0 to 1 as a percentage
+}
+
+// Choice is a value offered for an option
+type Choice struct {
+	Label string
+	Value any
+}
+
+// Setting returns the named setting from ctx, or the zero value if it's
+// missing or of another type
+func Setting[T any](ctx *ToolContext, name string) T {
+	v, _ := ctx.Settings[name].(T)
+	return v
+}
+
+// Option returns the tool's option with the given name, or nil
+func (t *Tool) Option(name string) *Option {
+	for i := range t.Options {
+		if t.Options[i].Name == name {
+			return &t.Options[i]
+		}
+	}
+	return nil
+}
+
+// Settings returns the values of the tool's options from stored, using the
+// default for any that are missing or invalid. stored isn't changed.
+func (t *Tool) Settings(stored Settings) Settings {
+	settings := make(Settings, len(t.Options))
+	for _, o := range t.Options {
+		settings[o.Name] = o.Default
+		if v, ok := stored[o.Name]; ok && o.check(v) == nil {
+			settings[o.Name] = v
+		}
+	}
+	return settings
+}
+
+// CheckSettings returns an error if settings name an option the tool
+// doesn't have or give one a value it doesn't allow
+func (t *Tool) CheckSettings(settings Settings) error {
+	// Sorted so that the same error is reported each time
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		o := t.Option(name)
+		if o == nil {
+			return fmt.Errorf("the %s tool has no %q option", t.Name, name)
+		}
+		if err := o.check(settings[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check returns an error if v isn't a value the option allows
+func (o *Option) check(v any) error {
+	switch def := o.Default.(type) {
+	case bool:
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s must be true or false", o.Name)
+		}
+	case float64:
+		n, ok := v.(float64)
+		if !ok || n < o.Min || n > o.Max {
+			return fmt.Errorf("%s must be a number from %v to %v", o.Name, o.Min, o.Max)
+		}
+	case string:
+		for _, c := range o.Choices {
+			if c.Value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown %s %q: use %s", o.Name, fmt.Sprint(v), o.choiceList())
+	default:
+		panic(fmt.Sprintf("option %q has a default of unsupported type %T", o.Name, def))
+	}
+	return nil
+}
+
+// choiceList returns the option's choices as a list for error messages,
+// e.g. "distance, dimensions or edges"
+func (o *Option) choiceList() string {
+	values := make([]string, len(o.Choices))
+	for i, c := range o.Choices {
+		values[i] = fmt.Sprint(c.Value)
+	}
+	if len(values) < 2 {
+		return strings.Join(values, "")
+	}
+	return strings.Join(values[:len(values)-1], ", ") + " or " + values[len(values)-1]
+}
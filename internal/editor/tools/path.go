@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+const (
+	// pathSamplesPerSegment is how many points each Catmull-Rom segment is
+	// flattened into when drawing a smoothed path
+	pathSamplesPerSegment = 8
+
+	// hitSlop is the extra distance, in pixels, a click may be from a thin
+	// stroke and still count as a hit
+	hitSlop = 4
+)
+
+// PathAnnotation represents a freehand pen stroke
+type PathAnnotation struct {
+	BaseAnnotation
+	Points []image.Point `json:"points"`
+}
+
+// NewPath creates a new freehand path annotation. The points are simplified
+// so that mouse jitter doesn't show up as wobbles in the stroke.
+func NewPath(points []image.Point, c color.Color, strokeWidth int) *PathAnnotation {
+	tolerance := max(1, float64(strokeWidth)/2)
+	return &PathAnnotation{
+		BaseAnnotation: BaseAnnotation{Color: c, StrokeWidth: strokeWidth},
+		Points:         SimplifyPath(points, tolerance),
+	}
+}
+
+// Draw renders the smoothed path onto the image with round joins and caps
+func (p *PathAnnotation) Draw(img *image.RGBA) {
+	if len(p.Points) == 0 {
+		return
+	}
+
+	s := newShape(img, p.Bounds())
+	s.polyline(fpoints(SmoothPath(p.Points, pathSamplesPerSegment)), float64(p.StrokeWidth))
+	s.draw(img, p.Color)
+}
+
+// Bounds returns the bounding box of the path
+func (p *PathAnnotation) Bounds() image.Rectangle {
+	if len(p.Points) == 0 {
+		return image.Rectangle{}
+	}
+
+	// Catmull-Rom curves can overshoot their control points slightly
+	r := image.Rectangle{Min: p.Points[0], Max: p.Points[0]}
+	for _, pt := range p.Points[1:] {
+		r.Min.X = min(r.Min.X, pt.X)
+		r.Min.Y = min(r.Min.Y, pt.Y)
+		r.Max.X = max(r.Max.X, pt.X)
+		r.Max.Y = max(r.Max.Y, pt.Y)
+	}
+	return r.Inset(-p.StrokeWidth * 2)
+}
+
+// Contains returns true if the point is on or near the stroke itself
+func (p *PathAnnotation) Contains(x, y int) bool {
+	if !image.Pt(x, y).In(p.Bounds()) {
+		return false
+	}
+
+	tolerance := float64(p.StrokeWidth)/2 + hitSlop
+	smoothed := SmoothPath(p.Points, pathSamplesPerSegment)
+	if len(smoothed) == 1 {
+		return distanceToSegment(image.Pt(x, y), smoothed[0], smoothed[0]) <= tolerance
+	}
+	for i := 1; i < len(smoothed); i++ {
+		if distanceToSegment(image.Pt(x, y), smoothed[i-1], smoothed[i]) <= tolerance {
+			return true
+		}
+	}
+	return false
+}
+
+// Transform moves the path to follow a change to the image geometry
+func (p *PathAnnotation) Transform(t Transform) {
+	for i, pt := range p.Points {
+		p.Points[i] = t.Apply(pt)
+	}
+	p.transformStroke(t)
+}
+
+// SVG returns the smoothed path as a polyline with round joins and caps
+func (p *PathAnnotation) SVG(id string) string {
+	if len(p.Points) == 0 {
+		return ""
+	}
+	points := fpoints(SmoothPath(p.Points, pathSamplesPerSegment))
+	if len(points) == 1 {
+		// A zero-length round-capped stroke renders as a dot
+		points = append(points, points[0])
+	}
+	return fmt.Sprintf(`<polyline points="%s" %s/>`, svgPoints(points), svgStroke(p.Color, float64(p.StrokeWidth), "round"))
+}
+
+// SimplifyPath reduces the number of points in a polyline using the
+// Ramer-Douglas-Peucker algorithm. Points closer than epsilon to the
+// simplified line are dropped.
+func SimplifyPath(points []image.Point, epsilon float64) []image.Point {
+	if len(points) < 3 {
+		return append([]image.Point(nil), points...)
+	}
+
+	keep := make([]bool, len(points))
+	keep[0] = true
+	keep[len(points)-1] = true
+	simplifyRange(points, 0, len(points)-1, epsilon, keep)
+
+	simplified := make([]image.Point, 0, len(points))
+	for i, pt := range points {
+		if keep[i] {
+			simplified = append(simplified, pt)
+		}
+	}
+	return simplified
+}
+
+func simplifyRange(points []image.Point, first, last int, epsilon float64, keep []bool) {
+	maxDist := 0.0
+	index := -1
+	for i := first + 1; i < last; i++ {
+		d := distanceToSegment(points[i], points[first], points[last])
+		if d > maxDist {
+			maxDist = d
+			index = i
+		}
+	}
+
+	if index < 0 || maxDist <= epsilon {
+		return
+	}
+
+	keep[index] = true
+	simplifyRange(points, first, index, epsilon, keep)
+	simplifyRange(points, index, last, epsilon, keep)
+}
+
+// SmoothPath interpolates a Catmull-Rom spline through the points, returning
+// a denser polyline that passes through every original point
+func SmoothPath(points []image.Point, samples int) []image.Point {
+	if len(points) < 3 || samples < 2 {
+		return append([]image.Point(nil), points...)
+	}
+
+	smoothed := make([]image.Point, 0, (len(points)-1)*samples+1)
+	for i := 0; i < len(points)-1; i++ {
+		// Duplicate the end points so the curve reaches them
+		p0 := points[max(i-1, 0)]
+		p1 := points[i]
+		p2 := points[i+1]
+		p3 := points[min(i+2, len(points)-1)]
+
+		for s := 0; s < samples; s++ {
+			t := float64(s) / float64(samples)
+			smoothed = append(smoothed, catmullRom(p0, p1, p2, p3, t))
+		}
+	}
+	smoothed = append(smoothed, points[len(points)-1])
+	return smoothed
+}
+
+func catmullRom(p0, p1, p2, p3 image.Point, t float64) image.Point {
+	t2 := t * t
+	t3 := t2 * t
+	interp := func(a, b, c, d int) int {
+		v := 0.5 * (2*float64(b) +
+			(-float64(a)+float64(c))*t +
+			(2*float64(a)-5*float64(b)+4*float64(c)-float64(d))*t2 +
+			(-float64(a)+3*float64(b)-3*float64(c)+float64(d))*t3)
+		return int(math.Round(v))
+	}
+	return image.Pt(interp(p0.X, p1.X, p2.X, p3.X), interp(p0.Y, p1.Y, p2.Y, p3.Y))
+}
+
+// distanceToSegment returns the distance from p to the line segment a-b
+func distanceToSegment(p, a, b image.Point) float64 {
+	dx := float64(b.X - a.X)
+	dy := float64(b.Y - a.Y)
+	px := float64(p.X - a.X)
+	py := float64(p.Y - a.Y)
+
+	lengthSq := dx*dx + dy*dy
+	if lengthSq == 0 {
+		return math.Hypot(px, py)
+	}
+
+	t := math.Max(0, math.Min(1, (px*dx+py*dy)/lengthSq))
+	return math.Hypot(px-t*dx, py-t*dy)
+}
@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestSimplifyPath(t *testing.T) {
+	// A nearly straight line with small jitter collapses to its end points
+	points := []image.Point{
+		{0, 0}, {10, 1}, {20, 0}, {30, 1}, {40, 0},
+	}
+	got := SimplifyPath(points, 2)
+	want := []image.Point{{0, 0}, {40, 0}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("SimplifyPath() = %v, want %v", got, want)
+	}
+
+	// A corner survives simplification
+	corner := []image.Point{
+		{0, 0}, {10, 0}, {20, 0}, {20, 10}, {20, 20},
+	}
+	got = SimplifyPath(corner, 2)
+	want = []image.Point{{0, 0}, {20, 0}, {20, 20}}
+	if len(got) != len(want) {
+		t.Fatalf("SimplifyPath() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("SimplifyPath()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSimplifyPathShortInput(t *testing.T) {
+	points := []image.Point{{1, 1}, {2, 2}}
+	got := SimplifyPath(points, 5)
+	if len(got) != 2 {
+		t.Errorf("SimplifyPath() should keep short paths, got %v", got)
+	}
+}
+
+func TestSmoothPathPassesThroughPoints(t *testing.T) {
+	points := []image.Point{{0, 0}, {50, 40}, {100, 0}, {150, 40}}
+	smoothed := SmoothPath(points, 8)
+
+	if len(smoothed) != (len(points)-1)*8+1 {
+		t.Fatalf("SmoothPath() returned %d points, want %d", len(smoothed), (len(points)-1)*8+1)
+	}
+	for i, pt := range points {
+		if smoothed[i*8] != pt {
+			t.Errorf("SmoothPath() should pass through %v, got %v", pt, smoothed[i*8])
+		}
+	}
+}
+
+func TestNewPath(t *testing.T) {
+	c := color.RGBA{R: 255, A: 255}
+	path := NewPath([]image.Point{{0, 0}, {10, 0}, {20, 0}}, c, 4)
+
+	if path.Color != c {
+		t.Errorf("Expected color %v, got %v", c, path.Color)
+	}
+	if path.StrokeWidth != 4 {
+		t.Errorf("Expected strokeWidth 4, got %d", path.StrokeWidth)
+	}
+	if len(path.Points) != 2 {
+		t.Errorf("Expected collinear points to be simplified, got %v", path.Points)
+	}
+}
+
+func TestPathContains(t *testing.T) {
+	// An L-shaped stroke; the inside corner of its bounding box is empty
+	path := NewPath([]image.Point{{10, 10}, {100, 10}, {100, 100}}, color.Black, 4)
+
+	tests := []struct {
+		name string
+		x    int
+		y    int
+		want bool
+	}{
+		{"at start", 10, 10, true},
+		{"at corner", 100, 10, true},
+		{"near corner", 104, 12, true},
+		{"at end", 100, 100, true},
+		{"inside bounds but off the stroke", 30, 80, false},
+		{"outside bounds", 200, 200, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := path.Contains(tt.x, tt.y); got != tt.want {
+				t.Errorf("Contains(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathDraw(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+
+	path := NewPath([]image.Point{{10, 10}, {100, 10}, {100, 100}}, color.RGBA{G: 255, A: 255}, 6)
+	path.Draw(img)
+
+	if img.RGBAAt(100, 10).G == 0 {
+		t.Error("Expected pixel on the path to be colored")
+	}
+	if img.RGBAAt(30, 80).G != 0 {
+		t.Error("Expected pixel away from the path to be untouched")
+	}
+}
+
+func TestPathDrawSinglePoint(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 50, 50))
+
+	path := NewPath([]image.Point{{25, 25}}, color.RGBA{B: 255, A: 255}, 6)
+	path.Draw(img)
+
+	if img.RGBAAt(25, 25).B == 0 {
+		t.Error("Expected a single-point path to draw a dot")
+	}
+}
@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// fpoint is a point in sub-pixel image coordinates
+type fpoint struct {
+	X, Y float64
+}
+
+// pt converts an integer image point to the centre of its pixel, so that
+// odd-width strokes land exactly on pixel boundaries and stay crisp
+func pt(p image.Point) fpoint {
+	return fpoint{X: float64(p.X) + 0.5, Y: float64(p.Y) + 0.5}
+}
+
+// shape accumulates anti-aliased coverage for part of an image and then
+// composites it in a single source-over pass. Every filled sub-shape is
+// wound the same way, so where they overlap the coverage saturates instead
+// of blending twice, and holes are wound the opposite way to cancel out.
+type shape struct {
+	z    *vector.Rasterizer
+	rect image.Rectangle
+}
+
+// newShape returns an empty shape covering the part of img within r
+func newShape(img *image.RGBA, r image.Rectangle) *shape {
+	r = r.Intersect(img.Bounds())
+	return &shape{
+		z:    vector.NewRasterizer(max(r.Dx(), 0), max(r.Dy(), 0)),
+		rect: r,
+	}
+}
+
+// draw composites the shape onto img in colour c using source-over alpha
+func (s *shape) draw(img *image.RGBA, c color.Color) {
+	if s.rect.Empty() {
+		return
+	}
+	s.z.Draw(img, s.rect, image.NewUniform(c), image.Point{})
+}
+
+// polygon adds a closed polygon. Holes are wound opposite to filled areas
+// so they subtract from anything they overlap.
+func (s *shape) polygon(points []fpoint, hole bool) {
+	if len(points) < 3 || s.rect.Empty() {
+		return
+	}
+
+	area := 0.0
+	for i := range points {
+		j := (i + 1) % len(points)
+		area += points[i].X*points[j].Y - points[j].X*points[i].Y
+	}
+	if area == 0 {
+		return
+	}
+
+	ox, oy := float64(s.rect.Min.X), float64(s.rect.Min.Y)
+	at := func(i int) (float32, float32) {
+		if (area < 0) != hole {
+			i = len(points) - 1 - i
+		}
+		return float32(points[i].X - ox), float32(points[i].Y - oy)
+	}
+
+	s.z.MoveTo(at(0))
+	for i := 1; i < len(points); i++ {
+		s.z.LineTo(at(i))
+	}
+	s.z.ClosePath()
+}
+
+// ellipse adds an axis-aligned ellipse
+func (s *shape) ellipse(c fpoint, rx, ry float64, hole bool) {
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+
+	// Enough segments that the flat edges are well under a pixel long
+	segments := max(16, int(math.Ceil(math.Pi*(rx+ry))))
+	points := make([]fpoint, segments)
+	for i := range points {
+		theta := 2 * math.Pi * float64(i) / float64(segments)
+		points[i] = fpoint{X: c.X + rx*math.Cos(theta), Y: c.Y + ry*math.Sin(theta)}
+	}
+	s.polygon(points, hole)
+}
+
+// circle adds a filled circle of the given diameter
+func (s *shape) circle(c fpoint, diameter float64) {
+	s.ellipse(c, diameter/2, diameter/2, false)
+}
+
+// segment adds a straight stroke from a to b. Square caps extend the stroke
+// by half its width at each end; otherwise the ends are flat.
+func (s *shape) segment(a, b fpoint, width float64, squareCaps bool) {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		if squareCaps {
+			h := width / 2
+			s.polygon([]fpoint{{a.X - h, a.Y - h}, {a.X + h, a.Y - h}, {a.X + h, a.Y + h}, {a.X - h, a.Y + h}}, false)
+		}
+		return
+	}
+
+	ux, uy := dx/length, dy/length
+	nx, ny := -uy*width/2, ux*width/2
+	if squareCaps {
+		a = fpoint{X: a.X - ux*width/2, Y: a.Y - uy*width/2}
+		b = fpoint{X: b.X + ux*width/2, Y: b.Y + uy*width/2}
+	}
+	s.polygon([]fpoint{
+		{a.X + nx, a.Y + ny},
+		{b.X + nx, b.Y + ny},
+		{b.X - nx, b.Y - ny},
+		{a.X - nx, a.Y - ny},
+	}, false)
+}
+
+// polyline adds an open stroke through points with round caps and joins
+func (s *shape) polyline(points []fpoint, width float64) {
+	for i, p := range points {
+		s.circle(p, width)
+		if i > 0 {
+			s.segment(points[i-1], p, width, false)
+		}
+	}
+}
+
+// arrowHead adds a solid triangular head with its tip at tip, pointing away
+// from `from`. It returns where the shaft should stop so its round cap stays
+// hidden inside the head rather than blunting the tip.
+func (s *shape) arrowHead(from, tip fpoint, strokeWidth int) fpoint {
+	head, shaftEnd := arrowHeadPoints(from, tip, strokeWidth)
+	if head != nil {
+		s.polygon(head, false)
+	}
+	return shaftEnd
+}
+
+// arrowHeadPoints returns the triangle of an arrow head with its tip at tip,
+// pointing away from `from`, and where the shaft should stop. The triangle
+// is nil if the arrow is too short to point anywhere.
+func arrowHeadPoints(from, tip fpoint, strokeWidth int) ([]fpoint, fpoint) {
+	headLength := float64(strokeWidth * 5)
+	headWidth := float64(strokeWidth * 3)
+
+	dx, dy := tip.X-from.X, tip.Y-from.Y
+	length := math.Hypot(dx, dy)
+	if length < 1 {
+		return nil, tip
+	}
+	dx, dy = dx/length, dy/length
+
+	base := fpoint{X: tip.X - dx*headLength, Y: tip.Y - dy*headLength}
+	perpX, perpY := -dy, dx
+	head := []fpoint{
+		tip,
+		{base.X + perpX*headWidth, base.Y + perpY*headWidth},
+		{base.X - perpX*headWidth, base.Y - perpY*headWidth},
+	}
+
+	back := math.Min(math.Max(headLength-float64(strokeWidth), 0), length)
+	return head, fpoint{X: tip.X - dx*back, Y: tip.Y - dy*back}
+}
+
+// fpoints converts integer image points to pixel-centre points
+func fpoints(points []image.Point) []fpoint {
+	converted := make([]fpoint, len(points))
+	for i, p := range points {
+		converted[i] = pt(p)
+	}
+	return converted
+}
+
+// mask returns the shape's coverage as an alpha mask over its rectangle
+func (s *shape) mask() *image.Alpha {
+	m := image.NewAlpha(s.rect)
+	if !s.rect.Empty() {
+		s.z.Draw(m, s.rect, image.Opaque, image.Point{})
+	}
+	return m
+}
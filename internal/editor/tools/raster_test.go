@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden images in testdata")
+
+// goldenTolerance allows for tiny floating point differences between
+// platforms without letting real rendering changes through
+const goldenTolerance = 2
+
+func TestGoldenImages(t *testing.T) {
+	red := color.RGBA{R: 220, G: 30, B: 30, A: 255}
+	blue := color.RGBA{R: 30, G: 90, B: 220, A: 255}
+	yellow := color.NRGBA{R: 255, G: 230, B: 0, A: 110}
+
+	curved := NewCurvedArrow(image.Pt(20, 100), image.Pt(180, 100), red, 4)
+	curved.MoveHandle(0, image.Pt(100, 20))
+
+	spotlight := NewSpotlight(color.Black, 0.5, false,
+		SpotlightRegion{Rect: image.Rect(20, 30, 110, 110), Shape: SpotlightRoundedRect, Radius: 16},
+		SpotlightRegion{Rect: image.Rect(80, 90, 180, 170), Shape: SpotlightEllipse},
+	)
+
+	tests := []struct {
+		name string
+		ann  Annotation
+	}{
+		{"arrow", NewArrow(image.Pt(20, 170), image.Pt(170, 30), red, 4)},
+		{"double_arrow", NewDoubleArrow(image.Pt(20, 100), image.Pt(180, 60), blue, 3)},
+		{"curved_arrow", curved},
+		{"rect", NewRect(image.Rect(30, 40, 170, 160), red, 5, false)},
+		{"ellipse", NewEllipse(image.Rect(20, 40, 180, 160), blue, 4, false)},
+		{"filled_ellipse", NewEllipse(image.Rect(50, 50, 150, 150), blue, 4, true)},
+		{"line", NewLine(image.Pt(25, 30), image.Pt(175, 170), red, 7)},
+		{"path", NewPath([]image.Point{{20, 150}, {60, 40}, {110, 140}, {180, 50}}, blue, 6)},
+		{"highlighter", NewHighlighter(image.Pt(20, 100), image.Pt(180, 110), yellow, 24)},
+		{"spotlight", spotlight},
+		{"magnifier", NewMagnifier(image.Rect(30, 30, 70, 60), image.Rect(0, 0, 200, 200), 2, blue, 3, true)},
+		{"measure", NewMeasure(image.Pt(20, 60), image.Pt(180, 60), MeasureDistance, red, 2, 2)},
+		{"measure_dimensions", NewMeasure(image.Pt(30, 60), image.Pt(170, 150), MeasureDimensions, blue, 2, 1)},
+		{"measure_vertical", NewMeasure(image.Pt(40, 20), image.Pt(40, 180), MeasureDistance, blue, 2, 1)},
+		{"text", NewText(image.Pt(100, 100), "Click here", red, 16)},
+		{"badge", NewBadge(image.Pt(100, 100), "7", blue, 24)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+			draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+			tt.ann.Draw(img)
+
+			path := filepath.Join("testdata", tt.name+".png")
+			if *update {
+				writeGolden(t, path, img)
+				return
+			}
+
+			want := readGolden(t, path)
+			if want.Bounds() != img.Bounds() {
+				t.Fatalf("Golden image %s has bounds %v, want %v", path, want.Bounds(), img.Bounds())
+			}
+			for y := 0; y < 200; y++ {
+				for x := 0; x < 200; x++ {
+					got := img.RGBAAt(x, y)
+					exp := color.RGBAModel.Convert(want.At(x, y)).(color.RGBA)
+					if !closeEnough(got, exp) {
+						t.Fatalf("Pixel (%d,%d) = %v, golden %v (run with -update to regenerate)", x, y, got, exp)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestOverlappingStrokesDoNotDoubleBlend(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	// A path that doubles back on itself covers the same pixels twice
+	path := &PathAnnotation{
+		BaseAnnotation: BaseAnnotation{Color: color.NRGBA{A: 128}, StrokeWidth: 8},
+		Points:         []image.Point{{10, 50}, {90, 50}, {10, 50}},
+	}
+	path.Draw(img)
+
+	single := img.RGBAAt(50, 50)
+	if single.R < 120 || single.R > 135 {
+		t.Errorf("Expected 50%% black over white (~127), got %v", single)
+	}
+	if joint := img.RGBAAt(88, 50); joint != single {
+		t.Errorf("Expected the turn to match the rest of the stroke, got %v and %v", joint, single)
+	}
+}
+
+func TestStrokesAreAntiAliased(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+
+	line := NewLine(image.Pt(10, 10), image.Pt(90, 60), color.RGBA{R: 255, A: 255}, 3)
+	line.Draw(img)
+
+	partial := false
+	for y := 0; y < 100 && !partial; y++ {
+		for x := 0; x < 100; x++ {
+			if a := img.RGBAAt(x, y).A; a > 0 && a < 255 {
+				partial = true
+				break
+			}
+		}
+	}
+	if !partial {
+		t.Error("Expected diagonal stroke edges to have partial coverage")
+	}
+}
+
+func closeEnough(a, b color.RGBA) bool {
+	diff := func(x, y uint8) int {
+		if x > y {
+			return int(x - y)
+		}
+		return int(y - x)
+	}
+	return diff(a.R, b.R) <= goldenTolerance && diff(a.G, b.G) <= goldenTolerance &&
+		diff(a.B, b.B) <= goldenTolerance && diff(a.A, b.A) <= goldenTolerance
+}
+
+func readGolden(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open golden image: %v (run with -update to create it)", err)
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("Failed to decode golden image: %v", err)
+	}
+	return img
+}
+
+func writeGolden(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create testdata directory: %v", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create golden image: %v", err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("Failed to encode golden image: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"slices"
+)
+
+// Drag is the pointer movement that draws an annotation, in image pixels
+type Drag struct {
+	Start image.Point
+	End   image.Point
+	Path  []image.Point // Every point passed through, for freehand tools
+}
+
+// Rect returns the rectangle spanned by the drag
+func (d Drag) Rect() image.Rectangle {
+	return image.Rectangle{Min: d.Start, Max: d.End}.Canon()
+}
+
+// ToolContext is the editor state a tool draws with
+type ToolContext struct {
+	Screenshot  *image.RGBA  // The image being annotated, without annotations
+	Annotations []Annotation // The annotations already drawn, in draw order
+
+	Color       color.Color
+	StrokeWidth int     // In image pixels
+	Filled      bool    // Fill closed shapes rather than outlining them
+	ScaleFactor float64 // Physical pixels per logical point
+
+	Settings Settings // The tool's options, as returned by Tool.Settings
+}
+
+// Tool is an annotation tool offered in the editor toolbar
+type Tool struct {
+	Name     string // Identifies the tool; its shortcut is configured as "tool.<name>"
+	Label    string // Shown in the shortcut help, e.g. "Arrow tool"
+	Icon     string // Name of the theme icon on its toolbar button, e.g. "arrowDown"
+	Shortcut string // Default key binding, e.g. "a"
+
+	// Options are the settings offered in the menu of the tool's toolbar
+	// button. A tool without options is selected by its button instead.
+	Options []Option
+
+	// Restyle applies changed settings to the annotations already drawn,
+	// as when the spotlight's dimming changes. Nil leaves them as they are.
+	Restyle func(ctx *ToolContext)
+
+	// Constrain snaps the end of a drag while Shift is held. Nil leaves it
+	// unconstrained.
+	Constrain func(start, end image.Point) image.Point
+
+	// Build returns the annotation a drag makes, or nil if the drag is too
+	// small to make one
+	Build func(ctx *ToolContext, d Drag) Annotation
+
+	// Preview draws a drag in progress over img, which already shows the
+	// screenshot and existing annotations. Nil draws the result of Build.
+	Preview func(img *image.RGBA, ctx *ToolContext, d Drag)
+
+	// Finish returns the annotation to add when a drag ends, or nil if
+	// there is none, as when a tool extends an existing annotation instead.
+	// Nil uses Build.
+	Finish func(ctx *ToolContext, d Drag) Annotation
+}
+
+// DrawPreview draws the tool's preview of a drag in progress onto img
+func (t *Tool) DrawPreview(img *image.RGBA, ctx *ToolContext, d Drag) {
+	if t.Preview != nil {
+		t.Preview(img, ctx, d)
+		return
+	}
+	if ann := t.Build(ctx, d); ann != nil {
+		ann.Draw(img)
+	}
+}
+
+// Complete returns the annotation to add for a finished drag, or nil
+func (t *Tool) Complete(ctx *ToolContext, d Drag) Annotation {
+	if t.Finish != nil {
+		return t.Finish(ctx, d)
+	}
+	return t.Build(ctx, d)
+}
+
+// registry holds the registered tools in toolbar order
+var registry []*Tool
+
+// RegisterTool adds a tool to the end of the toolbar. It panics if the
+// tool has no name or Build function, or its name is already taken, as
+// those are programming errors.
+func RegisterTool(t Tool) {
+	if t.Name == "" || t.Build == nil {
+		panic("tool registered without a name or Build function")
+	}
+	if LookupTool(t.Name) != nil {
+		panic(fmt.Sprintf("tool %q registered twice", t.Name))
+	}
+	registry = append(registry, &t)
+}
+
+// Tools returns the registered tools in toolbar order
+func Tools() []*Tool {
+	return slices.Clone(registry)
+}
+
+// LookupTool returns the registered tool with the given name, or nil
+func LookupTool(name string) *Tool {
+	for _, t := range registry {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
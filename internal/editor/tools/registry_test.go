@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// testToolContext returns a context for drawing with the named tool, using
+// its default settings
+func testToolContext(name string) *ToolContext {
+	return &ToolContext{
+		Screenshot:  image.NewRGBA(image.Rect(0, 0, 200, 200)),
+		Color:       color.RGBA{R: 255, A: 255},
+		StrokeWidth: 3,
+		ScaleFactor: 2,
+		Settings:    LookupTool(name).Settings(nil),
+	}
+}
+
+func TestBuiltinToolsRegistered(t *testing.T) {
+	want := []string{"arrow", "rectangle", "highlighter", "pen", "ellipse", "line",
+		"double_arrow", "curved_arrow", "spotlight", "magnifier", "measure"}
+
+	got := Tools()
+	if len(got) != len(want) {
+		t.Fatalf("Tools() returned %d tools, want %d", len(got), len(want))
+	}
+	for i, name := range want {
+		if got[i].Name != name {
+			t.Errorf("Tools()[%d] = %q, want %q", i, got[i].Name, name)
+		}
+		if LookupTool(name) != got[i] {
+			t.Errorf("LookupTool(%q) didn't return the registered tool", name)
+		}
+		if got[i].Label == "" || got[i].Icon == "" || got[i].Shortcut == "" {
+			t.Errorf("tool %q is missing its label, icon or shortcut", name)
+		}
+	}
+	if LookupTool("missing") != nil {
+		t.Error("LookupTool(missing) should return nil")
+	}
+}
+
+func TestBuiltinToolsBuild(t *testing.T) {
+	d := Drag{
+		Start: image.Pt(20, 30),
+		End:   image.Pt(120, 90),
+		Path:  []image.Point{{20, 30}, {70, 60}, {120, 90}},
+	}
+	for _, tool := range Tools() {
+		ann := tool.Complete(testToolContext(tool.Name), d)
+		if ann == nil {
+			t.Errorf("tool %q made no annotation", tool.Name)
+			continue
+		}
+		if name := TypeName(ann); name == "" {
+			t.Errorf("tool %q made an annotation the codec can't save: %T", tool.Name, ann)
+		}
+
+		img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+		tool.DrawPreview(img, testToolContext(tool.Name), d)
+		if !slices.ContainsFunc(img.Pix, func(v uint8) bool { return v != 0 }) {
+			t.Errorf("tool %q drew nothing in its preview", tool.Name)
+		}
+	}
+}
+
+func TestToolsIgnoreEmptyDrags(t *testing.T) {
+	d := Drag{Start: image.Pt(50, 50), End: image.Pt(50, 50)}
+	for _, name := range []string{"spotlight", "magnifier", "measure"} {
+		if ann := LookupTool(name).Complete(testToolContext(name), d); ann != nil {
+			t.Errorf("tool %q made %T from an empty drag", name, ann)
+		}
+	}
+}
+
+func TestSpotlightToolExtendsExisting(t *testing.T) {
+	existing := NewSpotlight(color.Black, 0.5, false, SpotlightRegion{Rect: image.Rect(0, 0, 10, 10)})
+	ctx := testToolContext("spotlight")
+	ctx.Annotations = []Annotation{existing}
+
+	ann := LookupTool("spotlight").Complete(ctx, Drag{Start: image.Pt(20, 20), End: image.Pt(60, 50)})
+	if ann != nil {
+		t.Fatalf("Complete() = %T, want nil when a spotlight already exists", ann)
+	}
+	if len(existing.Regions) != 2 {
+		t.Fatalf("spotlight has %d regions, want 2", len(existing.Regions))
+	}
+	if got := existing.Regions[1]; got.Rect != image.Rect(20, 20, 60, 50) || got.Radius != spotlightRadius*2 {
+		t.Errorf("added region = %+v", got)
+	}
+}
+
+func TestHighlighterToolOpacity(t *testing.T) {
+	ctx := testToolContext("highlighter")
+	ctx.Settings["opacity"] = 0.6
+
+	ann := LookupTool("highlighter").Complete(ctx, Drag{Start: image.Pt(20, 20), End: image.Pt(80, 20)})
+	h, ok := ann.(*HighlighterAnnotation)
+	if !ok {
+		t.Fatalf("Complete() = %T, want *HighlighterAnnotation", ann)
+	}
+	if _, _, _, a := h.Color.RGBA(); a>>8 != 153 {
+		t.Errorf("highlighter alpha = %d, want 153 for 60%% opacity", a>>8)
+	}
+}
+
+func TestMeasureToolDetectsEdges(t *testing.T) {
+	ctx := testToolContext("measure")
+	// A white band from x=40 to x=140 on a black background
+	for y := range 200 {
+		for x := 40; x < 140; x++ {
+			ctx.Screenshot.Set(x, y, color.White)
+		}
+	}
+	for x := 0; x < 200; x++ {
+		if x < 40 || x >= 140 {
+			for y := range 200 {
+				ctx.Screenshot.Set(x, y, color.Black)
+			}
+		}
+	}
+	ctx.Settings["mode"] = "edges"
+
+	ann := LookupTool("measure").Complete(ctx, Drag{Start: image.Pt(60, 100), End: image.Pt(90, 105)})
+	m, ok := ann.(*MeasureAnnotation)
+	if !ok {
+		t.Fatalf("Complete() = %T, want *MeasureAnnotation", ann)
+	}
+	if m.Start != image.Pt(40, 100) || m.End != image.Pt(140, 100) {
+		t.Errorf("measured %v to %v, want (40,100) to (140,100)", m.Start, m.End)
+	}
+}
+
+func TestToolSettings(t *testing.T) {
+	tool := LookupTool("magnifier")
+
+	got := tool.Settings(Settings{"zoom": 3.0, "connector": "yes", "unknown": 1.0})
+	want := Settings{"zoom": 3.0, "connector": true}
+	if !maps.Equal(got, want) {
+		t.Errorf("Settings() = %v, want %v with the invalid connector replaced by its default", got, want)
+	}
+
+	if err := tool.CheckSettings(Settings{"zoom": 4.0, "connector": false}); err != nil {
+		t.Errorf("CheckSettings() error = %v", err)
+	}
+	for _, s := range []Settings{{"zoom": 8.0}, {"connector": "no"}, {"shape": "ellipse"}} {
+		if err := tool.CheckSettings(s); err == nil {
+			t.Errorf("CheckSettings(%v) should fail", s)
+		}
+	}
+	if err := LookupTool("measure").CheckSettings(Settings{"mode": "area"}); err == nil {
+		t.Error("CheckSettings() should reject a mode that isn't one of the choices")
+	}
+}
+
+func TestSpotlightToolRestyle(t *testing.T) {
+	ctx := testToolContext("spotlight")
+	existing := NewSpotlight(color.Black, 0.5, false, SpotlightRegion{Rect: image.Rect(0, 0, 10, 10)})
+	ctx.Annotations = []Annotation{existing}
+	ctx.Settings["opacity"] = 0.8
+	ctx.Settings["desaturate"] = true
+
+	LookupTool("spotlight").Restyle(ctx)
+	if existing.DimOpacity != 0.8 || !existing.Desaturate {
+		t.Errorf("restyled spotlight = %v/%v, want 0.8/true", existing.DimOpacity, existing.Desaturate)
+	}
+}
+
+func TestRegisterToolRejectsDuplicates(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterTool() didn't panic on a duplicate name")
+		}
+	}()
+	RegisterTool(Tool{Name: "arrow", Build: func(*ToolContext, Drag) Annotation { return nil }})
+}
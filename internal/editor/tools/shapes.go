@@ -0,0 +1,366 @@
+package tools
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Adjustable is implemented by annotations that expose handles which can be
+// dragged after the annotation has been drawn
+type Adjustable interface {
+	// Handles returns the positions of the annotation's handles
+	Handles() []image.Point
+
+	// MoveHandle moves the handle at index i to p
+	MoveHandle(i int, p image.Point)
+}
+
+// EllipseAnnotation represents an ellipse or circle annotation
+type EllipseAnnotation struct {
+	BaseAnnotation
+	Rect   image.Rectangle `json:"rect"`
+	Filled bool            `json:"filled"`
+}
+
+// NewEllipse creates a new ellipse annotation inscribed in rect
+func NewEllipse(rect image.Rectangle, c color.Color, strokeWidth int, filled bool) *EllipseAnnotation {
+	return &EllipseAnnotation{
+		BaseAnnotation: BaseAnnotation{Color: c, StrokeWidth: strokeWidth},
+		Rect:           rect.Canon(),
+		Filled:         filled,
+	}
+}
+
+// Draw renders the ellipse onto the image
+func (e *EllipseAnnotation) Draw(img *image.RGBA) {
+	s := newShape(img, e.Bounds())
+	cx, cy, rx, ry := e.geometry()
+	centre := fpoint{X: cx + 0.5, Y: cy + 0.5}
+	half := float64(e.StrokeWidth) / 2
+
+	s.ellipse(centre, rx+half, ry+half, false)
+	if !e.Filled && rx > half && ry > half {
+		s.ellipse(centre, rx-half, ry-half, true)
+	}
+	s.draw(img, e.Color)
+}
+
+// Bounds returns the bounding box of the ellipse
+func (e *EllipseAnnotation) Bounds() image.Rectangle {
+	return e.Rect.Inset(-e.StrokeWidth)
+}
+
+// Contains returns true if the point is inside a filled ellipse, or near the
+// outline of an unfilled one
+func (e *EllipseAnnotation) Contains(x, y int) bool {
+	cx, cy, rx, ry := e.geometry()
+	px, py := float64(x), float64(y)
+	if e.Filled {
+		return ellipseDistance(px, py, cx, cy, rx, ry) <= 1
+	}
+
+	tolerance := float64(e.StrokeWidth)/2 + hitSlop
+	return ellipseDistance(px, py, cx, cy, rx+tolerance, ry+tolerance) <= 1 &&
+		(rx <= tolerance || ry <= tolerance || ellipseDistance(px, py, cx, cy, rx-tolerance, ry-tolerance) > 1)
+}
+
+// Transform moves the ellipse to follow a change to the image geometry
+func (e *EllipseAnnotation) Transform(t Transform) {
+	e.Rect = t.ApplyRect(e.Rect)
+	e.transformStroke(t)
+}
+
+// SVG returns the ellipse as an SVG ellipse
+func (e *EllipseAnnotation) SVG(id string) string {
+	cx, cy, rx, ry := e.geometry()
+	centre := fmt.Sprintf(`cx="%s" cy="%s"`, svgNum(cx+0.5), svgNum(cy+0.5))
+	if e.Filled {
+		half := float64(e.StrokeWidth) / 2
+		return fmt.Sprintf(`<ellipse %s rx="%s" ry="%s" %s/>`, centre, svgNum(rx+half), svgNum(ry+half), svgPaint("fill", e.Color))
+	}
+	return fmt.Sprintf(`<ellipse %s rx="%s" ry="%s" %s/>`, centre, svgNum(rx), svgNum(ry), svgStroke(e.Color, float64(e.StrokeWidth), "butt"))
+}
+
+func (e *EllipseAnnotation) geometry() (cx, cy, rx, ry float64) {
+	cx = float64(e.Rect.Min.X+e.Rect.Max.X) / 2
+	cy = float64(e.Rect.Min.Y+e.Rect.Max.Y) / 2
+	rx = float64(e.Rect.Dx()) / 2
+	ry = float64(e.Rect.Dy()) / 2
+	return
+}
+
+// ellipseDistance returns the normalised distance of a point from the centre
+// of an ellipse; values at or below 1 are inside
+func ellipseDistance(px, py, cx, cy, rx, ry float64) float64 {
+	if rx <= 0 || ry <= 0 {
+		return math.Inf(1)
+	}
+	dx := (px - cx) / rx
+	dy := (py - cy) / ry
+	return dx*dx + dy*dy
+}
+
+// LineAnnotation represents a plain straight line
+type LineAnnotation struct {
+	BaseAnnotation
+	Start image.Point `json:"start"`
+	End   image.Point `json:"end"`
+}
+
+// NewLine creates a new line annotation
+func NewLine(start, end image.Point, c color.Color, strokeWidth int) *LineAnnotation {
+	return &LineAnnotation{
+		BaseAnnotation: BaseAnnotation{Color: c, StrokeWidth: strokeWidth},
+		Start:          start,
+		End:            end,
+	}
+}
+
+// Draw renders the line onto the image
+func (l *LineAnnotation) Draw(img *image.RGBA) {
+	s := newShape(img, l.Bounds())
+	s.polyline([]fpoint{pt(l.Start), pt(l.End)}, float64(l.StrokeWidth))
+	s.draw(img, l.Color)
+}
+
+// Bounds returns the bounding box of the line
+func (l *LineAnnotation) Bounds() image.Rectangle {
+	return image.Rectangle{Min: l.Start, Max: l.End}.Canon().Inset(-l.StrokeWidth)
+}
+
+// Contains returns true if the point is on or near the line
+func (l *LineAnnotation) Contains(x, y int) bool {
+	return distanceToSegment(image.Pt(x, y), l.Start, l.End) <= float64(l.StrokeWidth)/2+hitSlop
+}
+
+// Transform moves the line to follow a change to the image geometry
+func (l *LineAnnotation) Transform(t Transform) {
+	l.Start, l.End = t.Apply(l.Start), t.Apply(l.End)
+	l.transformStroke(t)
+}
+
+// SVG returns the line as a round-capped SVG line
+func (l *LineAnnotation) SVG(id string) string {
+	return svgLine(pt(l.Start), pt(l.End), l.Color, float64(l.StrokeWidth), "round")
+}
+
+// DoubleArrowAnnotation represents a line with an arrowhead at each end
+type DoubleArrowAnnotation struct {
+	BaseAnnotation
+	Start image.Point `json:"start"`
+	End   image.Point `json:"end"`
+}
+
+// NewDoubleArrow creates a new double-headed arrow annotation
+func NewDoubleArrow(start, end image.Point, c color.Color, strokeWidth int) *DoubleArrowAnnotation {
+	return &DoubleArrowAnnotation{
+		BaseAnnotation: BaseAnnotation{Color: c, StrokeWidth: strokeWidth},
+		Start:          start,
+		End:            end,
+	}
+}
+
+// Draw renders the double-headed arrow onto the image
+func (a *DoubleArrowAnnotation) Draw(img *image.RGBA) {
+	s := newShape(img, a.Bounds().Inset(-a.StrokeWidth*6))
+	shaftStart := s.arrowHead(pt(a.End), pt(a.Start), a.StrokeWidth)
+	shaftEnd := s.arrowHead(pt(a.Start), pt(a.End), a.StrokeWidth)
+	s.polyline([]fpoint{shaftStart, shaftEnd}, float64(a.StrokeWidth))
+	s.draw(img, a.Color)
+}
+
+// Bounds returns the bounding box of the arrow
+func (a *DoubleArrowAnnotation) Bounds() image.Rectangle {
+	return image.Rectangle{Min: a.Start, Max: a.End}.Canon().Inset(-a.StrokeWidth)
+}
+
+// Contains returns true if the point is on or near the arrow's shaft
+func (a *DoubleArrowAnnotation) Contains(x, y int) bool {
+	return distanceToSegment(image.Pt(x, y), a.Start, a.End) <= float64(a.StrokeWidth)/2+hitSlop
+}
+
+// Transform moves the arrow to follow a change to the image geometry
+func (a *DoubleArrowAnnotation) Transform(t Transform) {
+	a.Start, a.End = t.Apply(a.Start), t.Apply(a.End)
+	a.transformStroke(t)
+}
+
+// SVG returns the arrow as a line with a solid head at each end
+func (a *DoubleArrowAnnotation) SVG(id string) string {
+	startHead, shaftStart := arrowHeadPoints(pt(a.End), pt(a.Start), a.StrokeWidth)
+	endHead, shaftEnd := arrowHeadPoints(pt(a.Start), pt(a.End), a.StrokeWidth)
+	return "<g>" + svgLine(shaftStart, shaftEnd, a.Color, float64(a.StrokeWidth), "round") +
+		svgPolygon(startHead, a.Color) + svgPolygon(endHead, a.Color) + "</g>"
+}
+
+// curveSegments is how many straight segments a curved arrow is flattened into
+const curveSegments = 32
+
+// CurvedArrowAnnotation represents an arrow that follows a quadratic Bézier
+// curve. The Control point can be dragged to change how much it bends.
+type CurvedArrowAnnotation struct {
+	BaseAnnotation
+	Start   image.Point `json:"start"`
+	End     image.Point `json:"end"`
+	Control image.Point `json:"control"`
+}
+
+// NewCurvedArrow creates a new curved arrow annotation with a gentle default
+// bend to the left of the direction of travel
+func NewCurvedArrow(start, end image.Point, c color.Color, strokeWidth int) *CurvedArrowAnnotation {
+	dx := float64(end.X - start.X)
+	dy := float64(end.Y - start.Y)
+	control := image.Pt(
+		(start.X+end.X)/2+int(dy/4),
+		(start.Y+end.Y)/2-int(dx/4),
+	)
+	return &CurvedArrowAnnotation{
+		BaseAnnotation: BaseAnnotation{Color: c, StrokeWidth: strokeWidth},
+		Start:          start,
+		End:            end,
+		Control:        control,
+	}
+}
+
+// Draw renders the curved arrow onto the image
+func (a *CurvedArrowAnnotation) Draw(img *image.RGBA) {
+	s := newShape(img, a.Bounds().Inset(-a.StrokeWidth*6))
+	head, shaft := a.geometry()
+	if head != nil {
+		s.polygon(head, false)
+	}
+	s.polyline(shaft, float64(a.StrokeWidth))
+	s.draw(img, a.Color)
+}
+
+// geometry returns the arrow head and the flattened shaft leading up to it
+func (a *CurvedArrowAnnotation) geometry() (head, shaft []fpoint) {
+	// Aim the head along the curve's tangent at the end, which points from
+	// the control point towards the end point
+	tip := pt(a.End)
+	head, shaftEnd := arrowHeadPoints(pt(a.Control), tip, a.StrokeWidth)
+	stop := math.Hypot(shaftEnd.X-tip.X, shaftEnd.Y-tip.Y)
+
+	shaft = make([]fpoint, 0, curveSegments+1)
+	for _, p := range a.curve() {
+		if math.Hypot(p.X-tip.X, p.Y-tip.Y) > stop {
+			shaft = append(shaft, p)
+		}
+	}
+	return head, append(shaft, shaftEnd)
+}
+
+// Bounds returns the bounding box of the curve. A quadratic Bézier always
+// lies within the triangle formed by its three points.
+func (a *CurvedArrowAnnotation) Bounds() image.Rectangle {
+	minX := min(a.Start.X, a.End.X, a.Control.X) - a.StrokeWidth
+	minY := min(a.Start.Y, a.End.Y, a.Control.Y) - a.StrokeWidth
+	maxX := max(a.Start.X, a.End.X, a.Control.X) + a.StrokeWidth
+	maxY := max(a.Start.Y, a.End.Y, a.Control.Y) + a.StrokeWidth
+	return image.Rect(minX, minY, maxX, maxY)
+}
+
+// Contains returns true if the point is on or near the curve
+func (a *CurvedArrowAnnotation) Contains(x, y int) bool {
+	tolerance := float64(a.StrokeWidth)/2 + hitSlop
+	points := a.points()
+	for i := 1; i < len(points); i++ {
+		if distanceToSegment(image.Pt(x, y), points[i-1], points[i]) <= tolerance {
+			return true
+		}
+	}
+	return false
+}
+
+// Transform moves the arrow to follow a change to the image geometry
+func (a *CurvedArrowAnnotation) Transform(t Transform) {
+	a.Start, a.End, a.Control = t.Apply(a.Start), t.Apply(a.End), t.Apply(a.Control)
+	a.transformStroke(t)
+}
+
+// SVG returns the arrow as a flattened curve and a solid head
+func (a *CurvedArrowAnnotation) SVG(id string) string {
+	head, shaft := a.geometry()
+	return fmt.Sprintf(`<g><polyline points="%s" %s/>%s</g>`,
+		svgPoints(shaft), svgStroke(a.Color, float64(a.StrokeWidth), "round"), svgPolygon(head, a.Color))
+}
+
+// Handles returns the curve's control point
+func (a *CurvedArrowAnnotation) Handles() []image.Point {
+	return []image.Point{a.Control}
+}
+
+// MoveHandle moves the curve's control point
+func (a *CurvedArrowAnnotation) MoveHandle(i int, p image.Point) {
+	if i == 0 {
+		a.Control = p
+	}
+}
+
+// curve flattens the curve into a polyline in pixel-centre coordinates
+func (a *CurvedArrowAnnotation) curve() []fpoint {
+	start, control, end := pt(a.Start), pt(a.Control), pt(a.End)
+	points := make([]fpoint, 0, curveSegments+1)
+	for i := 0; i <= curveSegments; i++ {
+		t := float64(i) / curveSegments
+		u := 1 - t
+		points = append(points, fpoint{
+			X: u*u*start.X + 2*u*t*control.X + t*t*end.X,
+			Y: u*u*start.Y + 2*u*t*control.Y + t*t*end.Y,
+		})
+	}
+	return points
+}
+
+// points flattens the curve into a polyline of whole pixels
+func (a *CurvedArrowAnnotation) points() []image.Point {
+	curve := a.curve()
+	points := make([]image.Point, len(curve))
+	for i, p := range curve {
+		points[i] = image.Pt(int(math.Floor(p.X)), int(math.Floor(p.Y)))
+	}
+	return points
+}
+
+// Constrain45 snaps end so that the line from start to end lies on the
+// nearest multiple of 45 degrees, keeping its length along that direction
+func Constrain45(start, end image.Point) image.Point {
+	dx := float64(end.X - start.X)
+	dy := float64(end.Y - start.Y)
+	if dx == 0 && dy == 0 {
+		return end
+	}
+
+	angle := math.Round(math.Atan2(dy, dx)/(math.Pi/4)) * (math.Pi / 4)
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	length := dx*cos + dy*sin
+	return image.Pt(
+		start.X+int(math.Round(length*cos)),
+		start.Y+int(math.Round(length*sin)),
+	)
+}
+
+// ConstrainSquare moves end so that start and end span a square, using the
+// larger of the two dimensions
+func ConstrainSquare(start, end image.Point) image.Point {
+	dx := end.X - start.X
+	dy := end.Y - start.Y
+	size := max(abs(dx), abs(dy))
+	return image.Pt(start.X+size*sign(dx), start.Y+size*sign(dy))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,188 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestNewEllipseNormalisesRect(t *testing.T) {
+	e := NewEllipse(image.Rect(100, 100, 10, 20), color.Black, 3, false)
+	if e.Rect != image.Rect(10, 20, 100, 100) {
+		t.Errorf("Expected canonical rect, got %v", e.Rect)
+	}
+}
+
+func TestEllipseDraw(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+
+	e := NewEllipse(image.Rect(50, 50, 150, 150), color.RGBA{R: 255, A: 255}, 4, false)
+	e.Draw(img)
+
+	if img.RGBAAt(50, 100).R == 0 {
+		t.Error("Expected pixel on the left of the outline to be colored")
+	}
+	if img.RGBAAt(100, 100).R != 0 {
+		t.Error("Expected centre of an outlined ellipse to be untouched")
+	}
+	if img.RGBAAt(52, 52).R != 0 {
+		t.Error("Expected bounding box corner to be outside the ellipse")
+	}
+}
+
+func TestEllipseDrawFilled(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+
+	e := NewEllipse(image.Rect(50, 50, 150, 150), color.RGBA{G: 255, A: 255}, 4, true)
+	e.Draw(img)
+
+	if img.RGBAAt(100, 100).G == 0 {
+		t.Error("Expected centre of a filled ellipse to be colored")
+	}
+	if img.RGBAAt(52, 52).G != 0 {
+		t.Error("Expected bounding box corner to be outside the ellipse")
+	}
+}
+
+func TestEllipseContains(t *testing.T) {
+	outline := NewEllipse(image.Rect(50, 50, 150, 150), color.Black, 4, false)
+	filled := NewEllipse(image.Rect(50, 50, 150, 150), color.Black, 4, true)
+
+	tests := []struct {
+		name string
+		ann  *EllipseAnnotation
+		x    int
+		y    int
+		want bool
+	}{
+		{"outline edge", outline, 50, 100, true},
+		{"outline centre", outline, 100, 100, false},
+		{"outline corner", outline, 52, 52, false},
+		{"filled centre", filled, 100, 100, true},
+		{"filled outside", filled, 10, 10, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ann.Contains(tt.x, tt.y); got != tt.want {
+				t.Errorf("Contains(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineDrawAndContains(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+
+	line := NewLine(image.Pt(10, 10), image.Pt(190, 190), color.RGBA{B: 255, A: 255}, 3)
+	line.Draw(img)
+
+	if img.RGBAAt(100, 100).B == 0 {
+		t.Error("Expected pixel on the line to be colored")
+	}
+	if !line.Contains(100, 101) {
+		t.Error("Expected point next to the line to be contained")
+	}
+	if line.Contains(150, 50) {
+		t.Error("Expected point away from the line not to be contained")
+	}
+}
+
+func TestDoubleArrowDrawsBothHeads(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+
+	arrow := NewDoubleArrow(image.Pt(30, 50), image.Pt(170, 50), color.RGBA{R: 255, A: 255}, 3)
+	arrow.Draw(img)
+
+	// Each head fans out from its tip, so pixels just beside the shaft near
+	// both ends should be colored
+	if img.RGBAAt(36, 54).R == 0 {
+		t.Error("Expected head at the start to be drawn")
+	}
+	if img.RGBAAt(164, 54).R == 0 {
+		t.Error("Expected head at the end to be drawn")
+	}
+	if img.RGBAAt(100, 54).R != 0 {
+		t.Error("Expected the middle of the shaft to be thin")
+	}
+}
+
+func TestCurvedArrowHandles(t *testing.T) {
+	arrow := NewCurvedArrow(image.Pt(0, 100), image.Pt(200, 100), color.Black, 3)
+
+	handles := arrow.Handles()
+	if len(handles) != 1 {
+		t.Fatalf("Expected one handle, got %d", len(handles))
+	}
+	if handles[0].X != 100 || handles[0].Y == 100 {
+		t.Errorf("Expected default control point to bend away from the line, got %v", handles[0])
+	}
+
+	arrow.MoveHandle(0, image.Pt(100, 180))
+	if arrow.Control != image.Pt(100, 180) {
+		t.Errorf("Expected control point to move, got %v", arrow.Control)
+	}
+
+	// The midpoint of a quadratic Bézier is halfway between the chord
+	// midpoint and the control point
+	if !arrow.Contains(100, 140) {
+		t.Error("Expected curve midpoint to be contained")
+	}
+	if arrow.Contains(100, 100) {
+		t.Error("Expected straight-line midpoint not to be contained once bent")
+	}
+}
+
+func TestCurvedArrowDraw(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+
+	arrow := NewCurvedArrow(image.Pt(0, 100), image.Pt(200, 100), color.RGBA{G: 255, A: 255}, 3)
+	arrow.MoveHandle(0, image.Pt(100, 180))
+	arrow.Draw(img)
+
+	if img.RGBAAt(100, 140).G == 0 {
+		t.Error("Expected curve midpoint to be colored")
+	}
+}
+
+func TestConstrain45(t *testing.T) {
+	start := image.Pt(100, 100)
+
+	tests := []struct {
+		name string
+		end  image.Point
+		want image.Point
+	}{
+		{"nearly horizontal", image.Pt(200, 105), image.Pt(200, 100)},
+		{"nearly vertical", image.Pt(97, 20), image.Pt(100, 20)},
+		{"nearly diagonal", image.Pt(150, 145), image.Pt(148, 148)},
+		{"no movement", image.Pt(100, 100), image.Pt(100, 100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Constrain45(start, tt.end); got != tt.want {
+				t.Errorf("Constrain45(%v, %v) = %v, want %v", start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstrainSquare(t *testing.T) {
+	start := image.Pt(100, 100)
+
+	tests := []struct {
+		end  image.Point
+		want image.Point
+	}{
+		{image.Pt(150, 120), image.Pt(150, 150)},
+		{image.Pt(90, 40), image.Pt(40, 40)},
+		{image.Pt(130, 60), image.Pt(140, 60)},
+	}
+
+	for _, tt := range tests {
+		if got := ConstrainSquare(start, tt.end); got != tt.want {
+			t.Errorf("ConstrainSquare(%v, %v) = %v, want %v", start, tt.end, got, tt.want)
+		}
+	}
+}
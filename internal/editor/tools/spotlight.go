@@ -0,0 +1,221 @@
+package tools
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+// SpotlightShape is the outline of a spotlit region
+type SpotlightShape int
+
+const (
+	SpotlightRoundedRect SpotlightShape = iota
+	SpotlightEllipse
+)
+
+// SpotlightRegion is an area left at full brightness by a spotlight
+type SpotlightRegion struct {
+	Rect   image.Rectangle `json:"rect"`
+	Shape  SpotlightShape  `json:"shape"`
+	Radius int             `json:"radius"` // Corner radius for rounded rectangles
+}
+
+// SpotlightAnnotation dims, and optionally desaturates, everything outside
+// one or more highlighted regions. It restyles the screenshot itself, so it
+// is drawn before any other annotation.
+type SpotlightAnnotation struct {
+	BaseAnnotation
+	Regions    []SpotlightRegion `json:"regions"`
+	DimOpacity float64           `json:"dim_opacity"` // 0 leaves the surroundings untouched, 1 blacks them out
+	Desaturate bool              `json:"desaturate"`
+}
+
+// NewSpotlight creates a new spotlight annotation. The colour is what the
+// surroundings are dimmed towards, usually black.
+func NewSpotlight(c color.Color, dimOpacity float64, desaturate bool, regions ...SpotlightRegion) *SpotlightAnnotation {
+	return &SpotlightAnnotation{
+		BaseAnnotation: BaseAnnotation{Color: c},
+		Regions:        regions,
+		DimOpacity:     dimOpacity,
+		Desaturate:     desaturate,
+	}
+}
+
+// AddRegion adds another highlighted region to the spotlight
+func (s *SpotlightAnnotation) AddRegion(r SpotlightRegion) {
+	s.Regions = append(s.Regions, r)
+}
+
+// Draw dims and desaturates everything outside the spotlit regions
+func (s *SpotlightAnnotation) Draw(img *image.RGBA) {
+	b := img.Bounds()
+
+	// Rasterise the regions as one filled shape so that overlapping regions
+	// merge rather than cancel, then treat everything uncovered as dimmed
+	lit := newShape(img, b)
+	for _, r := range s.Regions {
+		lit.region(r)
+	}
+	coverage := lit.mask()
+
+	dim := image.NewAlpha(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dim.SetAlpha(x, y, color.Alpha{A: 0xff - coverage.AlphaAt(x, y).A})
+		}
+	}
+
+	if s.Desaturate {
+		desaturate(img, dim)
+	}
+
+	opacity := math.Max(0, math.Min(1, s.DimOpacity))
+	if opacity > 0 {
+		r, g, bl, _ := s.Color.RGBA()
+		tint := color.NRGBA64{R: uint16(r), G: uint16(g), B: uint16(bl), A: uint16(opacity * 0xffff)}
+		draw.DrawMask(img, b, image.NewUniform(tint), image.Point{}, dim, b.Min, draw.Over)
+	}
+}
+
+// Bounds returns the box around all of the spotlit regions
+func (s *SpotlightAnnotation) Bounds() image.Rectangle {
+	var r image.Rectangle
+	for _, region := range s.Regions {
+		r = r.Union(region.Rect)
+	}
+	return r
+}
+
+// Contains returns true if the point is inside one of the spotlit regions
+func (s *SpotlightAnnotation) Contains(x, y int) bool {
+	p := image.Pt(x, y)
+	for _, r := range s.Regions {
+		switch r.Shape {
+		case SpotlightEllipse:
+			cx := float64(r.Rect.Min.X+r.Rect.Max.X) / 2
+			cy := float64(r.Rect.Min.Y+r.Rect.Max.Y) / 2
+			if ellipseDistance(float64(x), float64(y), cx, cy, float64(r.Rect.Dx())/2, float64(r.Rect.Dy())/2) <= 1 {
+				return true
+			}
+		default:
+			if p.In(r.Rect) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Transform moves the spotlit regions to follow a change to the image geometry
+func (s *SpotlightAnnotation) Transform(t Transform) {
+	for i, r := range s.Regions {
+		s.Regions[i].Rect = t.ApplyRect(r.Rect)
+		if r.Radius > 0 {
+			s.Regions[i].Radius = t.ApplyLength(r.Radius)
+		}
+	}
+}
+
+// SVG returns the spotlight as a masked dimming layer. When desaturating, a
+// greyscale copy of the screenshot is laid under the dimming through the
+// same mask.
+func (s *SpotlightAnnotation) SVG(id string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<g><defs><mask id="%s-mask"><rect width="100%%" height="100%%" fill="#ffffff"/>`, id)
+	for _, r := range s.Regions {
+		switch r.Shape {
+		case SpotlightEllipse:
+			c := rectCentre(r.Rect)
+			fmt.Fprintf(&b, `<ellipse cx="%s" cy="%s" rx="%s" ry="%s" fill="#000000"/>`,
+				svgNum(c.X), svgNum(c.Y), svgNum(float64(r.Rect.Dx())/2), svgNum(float64(r.Rect.Dy())/2))
+		default:
+			radius := math.Min(float64(r.Radius), math.Min(float64(r.Rect.Dx()), float64(r.Rect.Dy()))/2)
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="%s" fill="#000000"/>`,
+				r.Rect.Min.X, r.Rect.Min.Y, r.Rect.Dx(), r.Rect.Dy(), svgNum(math.Max(0, radius)))
+		}
+	}
+	b.WriteString(`</mask>`)
+	if s.Desaturate {
+		fmt.Fprintf(&b, `<filter id="%s-grey"><feColorMatrix type="saturate" values="0"/></filter>`, id)
+	}
+	b.WriteString(`</defs>`)
+
+	if s.Desaturate {
+		fmt.Fprintf(&b, `<use href="#%s" filter="url(#%s-grey)" mask="url(#%s-mask)"/>`, ScreenshotID, id, id)
+	}
+	opacity := math.Max(0, math.Min(1, s.DimOpacity))
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" %s fill-opacity="%s" mask="url(#%s-mask)"/></g>`,
+		svgPaint("fill", opaque(s.Color)), svgNum(opacity), id)
+	return b.String()
+}
+
+// underlay marks spotlights as restyling the screenshot beneath other annotations
+func (s *SpotlightAnnotation) underlay() {}
+
+// region adds a spotlit region to the shape
+func (s *shape) region(r SpotlightRegion) {
+	lo := fpoint{X: float64(r.Rect.Min.X), Y: float64(r.Rect.Min.Y)}
+	hi := fpoint{X: float64(r.Rect.Max.X), Y: float64(r.Rect.Max.Y)}
+	switch r.Shape {
+	case SpotlightEllipse:
+		s.ellipse(fpoint{X: (lo.X + hi.X) / 2, Y: (lo.Y + hi.Y) / 2}, (hi.X-lo.X)/2, (hi.Y-lo.Y)/2, false)
+	default:
+		s.roundedRect(lo, hi, float64(r.Radius))
+	}
+}
+
+// roundedRect adds a filled rectangle with circular corners
+func (s *shape) roundedRect(lo, hi fpoint, radius float64) {
+	radius = math.Min(radius, math.Min(hi.X-lo.X, hi.Y-lo.Y)/2)
+	if radius <= 0 {
+		s.polygon([]fpoint{{lo.X, lo.Y}, {hi.X, lo.Y}, {hi.X, hi.Y}, {lo.X, hi.Y}}, false)
+		return
+	}
+
+	steps := max(4, int(math.Ceil(radius/2)))
+	corners := []struct {
+		c     fpoint
+		start float64
+	}{
+		{fpoint{hi.X - radius, lo.Y + radius}, -math.Pi / 2},
+		{fpoint{hi.X - radius, hi.Y - radius}, 0},
+		{fpoint{lo.X + radius, hi.Y - radius}, math.Pi / 2},
+		{fpoint{lo.X + radius, lo.Y + radius}, math.Pi},
+	}
+
+	points := make([]fpoint, 0, 4*(steps+1))
+	for _, corner := range corners {
+		for i := 0; i <= steps; i++ {
+			theta := corner.start + (math.Pi/2)*float64(i)/float64(steps)
+			points = append(points, fpoint{
+				X: corner.c.X + radius*math.Cos(theta),
+				Y: corner.c.Y + radius*math.Sin(theta),
+			})
+		}
+	}
+	s.polygon(points, false)
+}
+
+// desaturate blends pixels towards their greyscale value in proportion to mask
+func desaturate(img *image.RGBA, mask *image.Alpha) {
+	b := img.Bounds().Intersect(mask.Bounds())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			amount := uint32(mask.AlphaAt(x, y).A)
+			if amount == 0 {
+				continue
+			}
+			c := img.RGBAAt(x, y)
+			// Rec. 601 luma
+			grey := (299*uint32(c.R) + 587*uint32(c.G) + 114*uint32(c.B)) / 1000
+			mix := func(v uint8) uint8 {
+				return uint8((uint32(v)*(255-amount) + grey*amount) / 255)
+			}
+			img.SetRGBA(x, y, color.RGBA{R: mix(c.R), G: mix(c.G), B: mix(c.B), A: c.A})
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func filledImage(c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestSpotlightDimsOutsideRegions(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	img := filledImage(white)
+
+	s := NewSpotlight(color.Black, 0.5, false,
+		SpotlightRegion{Rect: image.Rect(10, 10, 40, 40), Shape: SpotlightRoundedRect, Radius: 6},
+		SpotlightRegion{Rect: image.Rect(50, 50, 90, 90), Shape: SpotlightEllipse},
+	)
+	s.Draw(img)
+
+	for _, p := range []image.Point{{25, 25}, {70, 70}} {
+		if got := img.RGBAAt(p.X, p.Y); got != white {
+			t.Errorf("Pixel %v inside a region = %v, want untouched %v", p, got, white)
+		}
+	}
+	for _, p := range []image.Point{{5, 5}, {95, 5}, {52, 52}} {
+		got := img.RGBAAt(p.X, p.Y)
+		if got.R < 120 || got.R > 135 {
+			t.Errorf("Pixel %v outside the regions = %v, want dimmed to about half", p, got)
+		}
+	}
+	// The rounded corner is outside the region, so it is dimmed
+	if got := img.RGBAAt(10, 10); got.R > 200 {
+		t.Errorf("Rounded corner pixel = %v, want dimmed", got)
+	}
+}
+
+func TestSpotlightOverlappingRegionsStayLit(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	img := filledImage(white)
+
+	s := NewSpotlight(color.Black, 0.8, false,
+		SpotlightRegion{Rect: image.Rect(10, 10, 60, 60)},
+		SpotlightRegion{Rect: image.Rect(30, 30, 80, 80)},
+	)
+	s.Draw(img)
+
+	if got := img.RGBAAt(45, 45); got != white {
+		t.Errorf("Pixel where regions overlap = %v, want untouched %v", got, white)
+	}
+}
+
+func TestSpotlightDesaturate(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	img := filledImage(red)
+
+	s := NewSpotlight(color.Black, 0, true, SpotlightRegion{Rect: image.Rect(40, 40, 60, 60)})
+	s.Draw(img)
+
+	if got := img.RGBAAt(50, 50); got != red {
+		t.Errorf("Pixel inside the region = %v, want untouched %v", got, red)
+	}
+	got := img.RGBAAt(5, 5)
+	if got.R != got.G || got.G != got.B {
+		t.Errorf("Pixel outside the region = %v, want grey", got)
+	}
+}
+
+func TestSpotlightContains(t *testing.T) {
+	s := NewSpotlight(color.Black, 0.5, false,
+		SpotlightRegion{Rect: image.Rect(0, 0, 20, 20)},
+		SpotlightRegion{Rect: image.Rect(40, 40, 80, 80), Shape: SpotlightEllipse},
+	)
+
+	tests := []struct {
+		name string
+		x, y int
+		want bool
+	}{
+		{"inside rect", 10, 10, true},
+		{"inside ellipse", 60, 60, true},
+		{"ellipse corner", 41, 41, false},
+		{"between regions", 30, 30, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Contains(tt.x, tt.y); got != tt.want {
+				t.Errorf("Contains(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+
+	if got, want := s.Bounds(), image.Rect(0, 0, 80, 80); got != want {
+		t.Errorf("Bounds() = %v, want %v", got, want)
+	}
+}
+
+func TestSpotlightTransform(t *testing.T) {
+	s := NewSpotlight(color.Black, 0.5, false, SpotlightRegion{Rect: image.Rect(10, 20, 30, 40), Radius: 4})
+	s.Transform(Transform{XX: 2, YY: 2})
+
+	if got, want := s.Regions[0].Rect, image.Rect(20, 40, 60, 80); got != want {
+		t.Errorf("Rect = %v, want %v", got, want)
+	}
+	if got := s.Regions[0].Radius; got != 8 {
+		t.Errorf("Radius = %d, want 8", got)
+	}
+}
+
+func TestRenderDrawsSpotlightsFirst(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	red := color.RGBA{R: 255, A: 255}
+	img := filledImage(white)
+
+	// The rectangle is added before the spotlight but sits outside its
+	// region, so it must not be dimmed
+	rect := NewRect(image.Rect(60, 60, 90, 90), red, 2, true)
+	spot := NewSpotlight(color.Black, 0.5, false, SpotlightRegion{Rect: image.Rect(0, 0, 30, 30)})
+	Render(img, []Annotation{rect, spot})
+
+	if got := img.RGBAAt(75, 75); got != red {
+		t.Errorf("Rectangle pixel = %v, want undimmed %v", got, red)
+	}
+	if got := img.RGBAAt(50, 10); got.R > 200 {
+		t.Errorf("Background pixel = %v, want dimmed", got)
+	}
+}
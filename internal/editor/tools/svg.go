@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ScreenshotID is the id of the screenshot <image> element in exported SVG
+// documents. Annotations that reuse the screenshot, such as magnifiers and
+// spotlights, refer to it by this id.
+const ScreenshotID = "screenshot"
+
+// svgNum formats a coordinate with at most two decimal places
+func svgNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// svgPaint returns a fill or stroke attribute for c, with an opacity
+// attribute when c is translucent
+func svgPaint(attr string, c color.Color) string {
+	hex := HexColor(c)
+	if len(hex) == 7 {
+		return fmt.Sprintf(`%s="%s"`, attr, hex)
+	}
+	alpha, _ := strconv.ParseUint(hex[7:], 16, 8)
+	return fmt.Sprintf(`%s="%s" %s-opacity="%s"`, attr, hex[:7], attr, svgNum(float64(alpha)/255))
+}
+
+// svgStroke returns the attributes for an unfilled stroke of colour c
+func svgStroke(c color.Color, width float64, cap string) string {
+	return fmt.Sprintf(`fill="none" %s stroke-width="%s" stroke-linecap="%s" stroke-linejoin="round"`,
+		svgPaint("stroke", c), svgNum(width), cap)
+}
+
+// svgPoints formats points for a polyline or polygon points attribute
+func svgPoints(points []fpoint) string {
+	parts := make([]string, len(points))
+	for i, p := range points {
+		parts[i] = svgNum(p.X) + "," + svgNum(p.Y)
+	}
+	return strings.Join(parts, " ")
+}
+
+// svgLine returns a straight stroke from a to b
+func svgLine(a, b fpoint, c color.Color, width float64, cap string) string {
+	return fmt.Sprintf(`<line x1="%s" y1="%s" x2="%s" y2="%s" %s/>`,
+		svgNum(a.X), svgNum(a.Y), svgNum(b.X), svgNum(b.Y), svgStroke(c, width, cap))
+}
+
+// svgPolygon returns a filled polygon, or nothing if there are no points
+func svgPolygon(points []fpoint, c color.Color) string {
+	if len(points) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`<polygon points="%s" %s/>`, svgPoints(points), svgPaint("fill", c))
+}
+
+// svgRect returns a rectangle between min and max with the given paint attributes
+func svgRect(min, max fpoint, attrs string) string {
+	return fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" %s/>`,
+		svgNum(min.X), svgNum(min.Y), svgNum(max.X-min.X), svgNum(max.Y-min.Y), attrs)
+}
+
+// opaque returns c with full alpha, for colours whose opacity is set separately
+func opaque(c color.Color) color.Color {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	n.A = 0xff
+	return n
+}
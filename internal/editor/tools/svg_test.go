@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"encoding/xml"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+	"testing"
+)
+
+// svgElements parses an SVG fragment and returns the names of its elements
+func svgElements(t *testing.T, fragment string) []string {
+	t.Helper()
+	dec := xml.NewDecoder(strings.NewReader("<svg>" + fragment + "</svg>"))
+	var names []string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return names
+		}
+		if err != nil {
+			t.Fatalf("Invalid SVG %q: %v", fragment, err)
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local != "svg" {
+			names = append(names, start.Name.Local)
+		}
+	}
+}
+
+func TestAnnotationSVGElements(t *testing.T) {
+	want := map[string][]string{
+		"arrow":        {"g", "line", "polygon"},
+		"rect":         {"rect"},
+		"highlighter":  {"line"},
+		"path":         {"polyline"},
+		"ellipse":      {"ellipse"},
+		"line":         {"line"},
+		"double_arrow": {"g", "line", "polygon", "polygon"},
+		"curved_arrow": {"g", "polyline", "polygon"},
+		"spotlight":    {"g", "defs", "mask", "rect", "rect", "ellipse", "filter", "feColorMatrix", "use", "rect"},
+		"magnifier":    {"g", "defs", "clipPath", "rect", "g", "use", "rect", "rect", "line"},
+		"measure":      {"g", "polyline", "rect", "text"},
+		"text":         {"g", "rect", "text"},
+		"badge":        {"g", "rect", "text"},
+	}
+
+	for name, ann := range sampleAnnotations() {
+		t.Run(name, func(t *testing.T) {
+			got := svgElements(t, ann.SVG("a1"))
+			if strings.Join(got, " ") != strings.Join(want[name], " ") {
+				t.Errorf("SVG() elements = %v, want %v", got, want[name])
+			}
+		})
+	}
+}
+
+func TestSVGPaint(t *testing.T) {
+	if got := svgPaint("fill", color.NRGBA{R: 255, A: 255}); got != `fill="#ff0000"` {
+		t.Errorf("svgPaint(opaque) = %s", got)
+	}
+	if got := svgPaint("stroke", color.NRGBA{R: 255, G: 230, A: 110}); got != `stroke="#ffe600" stroke-opacity="0.43"` {
+		t.Errorf("svgPaint(translucent) = %s", got)
+	}
+}
+
+func TestArrowSVGMatchesRaster(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	svg := NewArrow(image.Pt(0, 0), image.Pt(100, 0), red, 2).SVG("a1")
+
+	// The head's tip sits on the end point's pixel centre, as when rasterised
+	if !strings.Contains(svg, `points="100.5,0.5 `) {
+		t.Errorf("SVG() = %s, want the head's tip at 100.5,0.5", svg)
+	}
+	if !strings.Contains(svg, `stroke-width="2"`) || !strings.Contains(svg, `stroke-linecap="round"`) {
+		t.Errorf("SVG() = %s, want a 2px round-capped shaft", svg)
+	}
+}
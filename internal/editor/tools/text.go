@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+)
+
+// TextAnnotation is a short callout: a line of text on a rounded pill of
+// the annotation colour
+type TextAnnotation struct {
+	BaseAnnotation
+	Centre image.Point `json:"centre"`
+	Text   string      `json:"text"`
+	Size   int         `json:"size"` // Text height in pixels
+}
+
+// NewText creates a new text callout centred on centre
+func NewText(centre image.Point, text string, c color.Color, size int) *TextAnnotation {
+	return &TextAnnotation{
+		BaseAnnotation: BaseAnnotation{Color: c},
+		Centre:         centre,
+		Text:           text,
+		Size:           size,
+	}
+}
+
+// label returns the callout's text and placement
+func (t *TextAnnotation) label() label {
+	return label{text: t.Text, centre: pt(t.Centre), size: float64(t.Size)}
+}
+
+// Draw renders the callout onto the image
+func (t *TextAnnotation) Draw(img *image.RGBA) {
+	t.label().draw(img, t.Color, contrastColor(t.Color))
+}
+
+// Bounds returns the bounding box of the pill
+func (t *TextAnnotation) Bounds() image.Rectangle {
+	return t.label().bounds()
+}
+
+// Contains returns true if the point is on the pill
+func (t *TextAnnotation) Contains(x, y int) bool {
+	return image.Pt(x, y).In(t.Bounds())
+}
+
+// Transform moves the callout, scaling its text with the image
+func (t *TextAnnotation) Transform(tr Transform) {
+	t.Centre = tr.Apply(t.Centre)
+	t.Size = tr.ApplyLength(t.Size)
+}
+
+// Handles returns the centre of the callout, for moving it
+func (t *TextAnnotation) Handles() []image.Point {
+	return []image.Point{t.Centre}
+}
+
+// MoveHandle moves the callout
+func (t *TextAnnotation) MoveHandle(i int, p image.Point) {
+	if i == 0 {
+		t.Centre = p
+	}
+}
+
+// SVG returns the callout as a rounded rectangle and text
+func (t *TextAnnotation) SVG(id string) string {
+	return "<g>" + t.label().svg(t.Color, contrastColor(t.Color)) + "</g>"
+}
+
+// BadgeAnnotation is a numbered step marker: a short label, usually a
+// number, in a filled circle
+type BadgeAnnotation struct {
+	BaseAnnotation
+	Centre image.Point `json:"centre"`
+	Text   string      `json:"text"`
+	Size   int         `json:"size"` // Text height in pixels
+}
+
+// NewBadge creates a new badge centred on centre
+func NewBadge(centre image.Point, text string, c color.Color, size int) *BadgeAnnotation {
+	return &BadgeAnnotation{
+		BaseAnnotation: BaseAnnotation{Color: c},
+		Centre:         centre,
+		Text:           text,
+		Size:           size,
+	}
+}
+
+// label returns the badge's text and placement
+func (b *BadgeAnnotation) label() label {
+	return label{text: b.Text, centre: pt(b.Centre), size: float64(b.Size), circle: true}
+}
+
+// Draw renders the badge onto the image
+func (b *BadgeAnnotation) Draw(img *image.RGBA) {
+	b.label().draw(img, b.Color, contrastColor(b.Color))
+}
+
+// Bounds returns the bounding box of the circle
+func (b *BadgeAnnotation) Bounds() image.Rectangle {
+	return b.label().bounds()
+}
+
+// Contains returns true if the point is within the circle
+func (b *BadgeAnnotation) Contains(x, y int) bool {
+	r := b.Bounds()
+	radius := float64(r.Dx()) / 2
+	centre := pt(b.Centre)
+	dx, dy := float64(x)+0.5-centre.X, float64(y)+0.5-centre.Y
+	return dx*dx+dy*dy <= radius*radius
+}
+
+// Transform moves the badge, scaling it with the image
+func (b *BadgeAnnotation) Transform(t Transform) {
+	b.Centre = t.Apply(b.Centre)
+	b.Size = t.ApplyLength(b.Size)
+}
+
+// Handles returns the centre of the badge, for moving it
+func (b *BadgeAnnotation) Handles() []image.Point {
+	return []image.Point{b.Centre}
+}
+
+// MoveHandle moves the badge
+func (b *BadgeAnnotation) MoveHandle(i int, p image.Point) {
+	if i == 0 {
+		b.Centre = p
+	}
+}
+
+// SVG returns the badge as a circle and text
+func (b *BadgeAnnotation) SVG(id string) string {
+	return "<g>" + b.label().svg(b.Color, contrastColor(b.Color)) + "</g>"
+}
@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestTextBoundsFitText(t *testing.T) {
+	short := NewText(image.Pt(100, 100), "Hi", color.Black, 16)
+	long := NewText(image.Pt(100, 100), "A much longer callout", color.Black, 16)
+
+	if short.Bounds().Dx() >= long.Bounds().Dx() {
+		t.Errorf("short text is %dpx wide, long text %dpx; want the long text wider", short.Bounds().Dx(), long.Bounds().Dx())
+	}
+	if !short.Contains(100, 100) || short.Contains(100, 140) {
+		t.Error("Contains() should cover the pill and nothing below it")
+	}
+}
+
+func TestBadgeIsCircular(t *testing.T) {
+	b := NewBadge(image.Pt(50, 50), "1", color.Black, 20)
+
+	r := b.Bounds()
+	if r.Dx() != r.Dy() {
+		t.Errorf("Bounds() = %v, want a square", r)
+	}
+	if !b.Contains(50, 50) {
+		t.Error("Contains() should include the centre")
+	}
+	if b.Contains(r.Min.X, r.Min.Y) {
+		t.Error("Contains() should exclude the corners of the bounding box")
+	}
+}
+
+func TestTextTransformScalesSize(t *testing.T) {
+	text := NewText(image.Pt(10, 20), "Note", color.Black, 12)
+	text.Transform(Transform{XX: 2, YY: 2})
+
+	if text.Centre != image.Pt(20, 40) || text.Size != 24 {
+		t.Errorf("after scaling: centre %v size %d, want (20,40) size 24", text.Centre, text.Size)
+	}
+}
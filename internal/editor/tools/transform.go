@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"image"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Transform is an affine mapping of image coordinates, used to move
+// annotations along with the screenshot when it is cropped, scaled, rotated
+// or flipped. A point (x, y) maps to (XX*x + XY*y + X0, YX*x + YY*y + Y0).
+type Transform struct {
+	XX, XY, X0 float64
+	YX, YY, Y0 float64
+}
+
+// Translate returns a transform that moves points by dx, dy
+func Translate(dx, dy float64) Transform {
+	return Transform{XX: 1, X0: dx, YY: 1, Y0: dy}
+}
+
+// Apply maps a point through the transform
+func (t Transform) Apply(p image.Point) image.Point {
+	x, y := float64(p.X), float64(p.Y)
+	return image.Pt(
+		int(math.Round(t.XX*x+t.XY*y+t.X0)),
+		int(math.Round(t.YX*x+t.YY*y+t.Y0)),
+	)
+}
+
+// ApplyRect maps both corners of a rectangle and returns the rectangle they
+// span. Rotations and flips map pixels, but a rectangle's Max is the edge
+// past its last pixel, so along an axis they mirror the result is moved one
+// pixel on to cover the same pixels.
+func (t Transform) ApplyRect(r image.Rectangle) image.Rectangle {
+	out := image.Rectangle{Min: t.Apply(r.Min), Max: t.Apply(r.Max)}.Canon()
+	if t.XX+t.XY < 0 {
+		out = out.Add(image.Pt(1, 0))
+	}
+	if t.YX+t.YY < 0 {
+		out = out.Add(image.Pt(0, 1))
+	}
+	return out
+}
+
+// ApplyLength scales a length, such as a stroke width, by the transform's
+// average scale factor. Lengths never shrink below one pixel.
+func (t Transform) ApplyLength(v int) int {
+	scale := math.Sqrt(math.Abs(t.XX*t.YY - t.XY*t.YX))
+	return max(1, int(math.Round(float64(v)*scale)))
+}
+
+// Crop returns a copy of the part of img within r, and the transform that
+// moves annotations to match
+func Crop(img *image.RGBA, r image.Rectangle) (*image.RGBA, Transform) {
+	r = r.Intersect(img.Bounds())
+	cropped := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	xdraw.Draw(cropped, cropped.Bounds(), img, r.Min, xdraw.Src)
+	return cropped, Translate(float64(-r.Min.X), float64(-r.Min.Y))
+}
+
+// Resize scales img to width x height using Catmull-Rom resampling, and
+// returns the transform that moves annotations to match
+func Resize(img *image.RGBA, width, height int) (*image.RGBA, Transform) {
+	b := img.Bounds()
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(resized, resized.Bounds(), img, b, xdraw.Src, nil)
+
+	sx := float64(width) / float64(b.Dx())
+	sy := float64(height) / float64(b.Dy())
+	t := Transform{XX: sx, X0: -float64(b.Min.X) * sx, YY: sy, Y0: -float64(b.Min.Y) * sy}
+	return resized, t
+}
+
+// Rotate90 rotates img a quarter turn, clockwise or anticlockwise, and
+// returns the transform that moves annotations to match
+func Rotate90(img *image.RGBA, clockwise bool) (*image.RGBA, Transform) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	rotated := image.NewRGBA(image.Rect(0, 0, h, w))
+
+	var t Transform
+	if clockwise {
+		// (x, y) -> (h-1-y, x)
+		t = Transform{XY: -1, X0: float64(h - 1), YX: 1}
+	} else {
+		// (x, y) -> (y, w-1-x)
+		t = Transform{XY: 1, YX: -1, Y0: float64(w - 1)}
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := t.Apply(image.Pt(x, y))
+			rotated.SetRGBA(p.X, p.Y, img.RGBAAt(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return rotated, t
+}
+
+// Flip mirrors img horizontally or vertically, and returns the transform
+// that moves annotations to match
+func Flip(img *image.RGBA, horizontal bool) (*image.RGBA, Transform) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	flipped := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	var t Transform
+	if horizontal {
+		t = Transform{XX: -1, X0: float64(w - 1), YY: 1}
+	} else {
+		t = Transform{XX: 1, YY: -1, Y0: float64(h - 1)}
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := t.Apply(image.Pt(x, y))
+			flipped.SetRGBA(p.X, p.Y, img.RGBAAt(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return flipped, t
+}
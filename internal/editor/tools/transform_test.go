@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// numberedImage returns an image where each pixel encodes its own position
+func numberedImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(y), A: 255})
+		}
+	}
+	return img
+}
+
+func TestCrop(t *testing.T) {
+	img := numberedImage(20, 10)
+
+	cropped, tr := Crop(img, image.Rect(5, 2, 15, 8))
+	if cropped.Bounds() != image.Rect(0, 0, 10, 6) {
+		t.Fatalf("Crop() bounds = %v, want (0,0)-(10,6)", cropped.Bounds())
+	}
+	if got := cropped.RGBAAt(0, 0); got.R != 5 || got.G != 2 {
+		t.Errorf("Crop() origin pixel = %v, want source (5,2)", got)
+	}
+	if got := tr.Apply(image.Pt(5, 2)); got != image.Pt(0, 0) {
+		t.Errorf("Crop() transform maps (5,2) to %v, want (0,0)", got)
+	}
+}
+
+func TestRotate90(t *testing.T) {
+	img := numberedImage(4, 3)
+
+	tests := []struct {
+		name      string
+		clockwise bool
+		src       image.Point
+		want      image.Point
+	}{
+		{"clockwise top-left", true, image.Pt(0, 0), image.Pt(2, 0)},
+		{"clockwise bottom-right", true, image.Pt(3, 2), image.Pt(0, 3)},
+		{"anticlockwise top-left", false, image.Pt(0, 0), image.Pt(0, 3)},
+		{"anticlockwise bottom-right", false, image.Pt(3, 2), image.Pt(2, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rotated, tr := Rotate90(img, tt.clockwise)
+			if rotated.Bounds() != image.Rect(0, 0, 3, 4) {
+				t.Fatalf("Rotate90() bounds = %v, want (0,0)-(3,4)", rotated.Bounds())
+			}
+			if got := tr.Apply(tt.src); got != tt.want {
+				t.Errorf("Rotate90() maps %v to %v, want %v", tt.src, got, tt.want)
+			}
+			px := rotated.RGBAAt(tt.want.X, tt.want.Y)
+			if int(px.R) != tt.src.X || int(px.G) != tt.src.Y {
+				t.Errorf("Rotate90() pixel at %v = %v, want source %v", tt.want, px, tt.src)
+			}
+		})
+	}
+}
+
+func TestFlip(t *testing.T) {
+	img := numberedImage(4, 3)
+
+	flipped, tr := Flip(img, true)
+	if got := tr.Apply(image.Pt(0, 1)); got != image.Pt(3, 1) {
+		t.Errorf("Flip(horizontal) maps (0,1) to %v, want (3,1)", got)
+	}
+	if px := flipped.RGBAAt(3, 1); px.R != 0 || px.G != 1 {
+		t.Errorf("Flip(horizontal) pixel = %v, want source (0,1)", px)
+	}
+
+	flipped, tr = Flip(img, false)
+	if got := tr.Apply(image.Pt(2, 0)); got != image.Pt(2, 2) {
+		t.Errorf("Flip(vertical) maps (2,0) to %v, want (2,2)", got)
+	}
+	if px := flipped.RGBAAt(2, 2); px.R != 2 || px.G != 0 {
+		t.Errorf("Flip(vertical) pixel = %v, want source (2,0)", px)
+	}
+}
+
+func TestFlipRect(t *testing.T) {
+	img := numberedImage(4, 3)
+
+	// The first column becomes the last
+	_, tr := Flip(img, true)
+	if got := tr.ApplyRect(image.Rect(0, 0, 1, 3)); got != image.Rect(3, 0, 4, 3) {
+		t.Errorf("Flip(horizontal) maps the first column to %v, want (3,0)-(4,3)", got)
+	}
+	_, tr = Flip(img, false)
+	if got := tr.ApplyRect(image.Rect(1, 0, 3, 2)); got != image.Rect(1, 1, 3, 3) {
+		t.Errorf("Flip(vertical) maps %v, want (1,1)-(3,3)", got)
+	}
+
+	// Four quarter turns bring a rectangle back where it started
+	r := image.Rect(1, 0, 3, 2)
+	rotated := img
+	for range 4 {
+		var rot Transform
+		rotated, rot = Rotate90(rotated, true)
+		r = rot.ApplyRect(r)
+	}
+	if r != image.Rect(1, 0, 3, 2) {
+		t.Errorf("four rotations give %v, want (1,0)-(3,2)", r)
+	}
+}
+
+func TestResize(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 50))
+	for y := 0; y < 50; y++ {
+		for x := 0; x < 100; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+
+	resized, tr := Resize(img, 50, 25)
+	if resized.Bounds() != image.Rect(0, 0, 50, 25) {
+		t.Fatalf("Resize() bounds = %v, want (0,0)-(50,25)", resized.Bounds())
+	}
+	if px := resized.RGBAAt(25, 12); px != (color.RGBA{R: 200, G: 100, B: 50, A: 255}) {
+		t.Errorf("Resize() should preserve flat colour, got %v", px)
+	}
+	if got := tr.Apply(image.Pt(80, 40)); got != image.Pt(40, 20) {
+		t.Errorf("Resize() maps (80,40) to %v, want (40,20)", got)
+	}
+	if got := tr.ApplyLength(6); got != 3 {
+		t.Errorf("Resize() scales stroke 6 to %d, want 3", got)
+	}
+}
+
+func TestAnnotationsTransform(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	shift := Translate(-10, -20)
+
+	arrow := NewArrow(image.Pt(10, 20), image.Pt(50, 60), red, 3)
+	arrow.Transform(shift)
+	if arrow.Start != image.Pt(0, 0) || arrow.End != image.Pt(40, 40) {
+		t.Errorf("Arrow after translate = %v-%v, want (0,0)-(40,40)", arrow.Start, arrow.End)
+	}
+
+	rect := NewRect(image.Rect(10, 20, 50, 60), red, 3, false)
+	_, rotate := Rotate90(image.NewRGBA(image.Rect(0, 0, 100, 80)), true)
+	rect.Transform(rotate)
+	// Rows 20 to 59 become columns 80-60 to 80-20
+	if rect.Rect != image.Rect(20, 10, 60, 50) {
+		t.Errorf("Rect after rotate = %v, want (20,10)-(60,50)", rect.Rect)
+	}
+
+	curved := NewCurvedArrow(image.Pt(0, 0), image.Pt(100, 0), red, 4)
+	curved.MoveHandle(0, image.Pt(50, 40))
+	curved.Transform(doubleSize())
+	if curved.Control != image.Pt(100, 80) || curved.End != image.Pt(200, 0) {
+		t.Errorf("Curved arrow after scale = control %v end %v", curved.Control, curved.End)
+	}
+	if curved.StrokeWidth != 8 {
+		t.Errorf("Curved arrow stroke after scale = %d, want 8", curved.StrokeWidth)
+	}
+
+	path := NewPath([]image.Point{{0, 0}, {10, 20}, {30, 0}}, red, 2)
+	path.Transform(shift)
+	if path.Points[0] != image.Pt(-10, -20) {
+		t.Errorf("Path after translate starts at %v, want (-10,-20)", path.Points[0])
+	}
+}
+
+// doubleSize returns the transform for doubling an image's size
+func doubleSize() Transform {
+	_, t := Resize(image.NewRGBA(image.Rect(0, 0, 10, 10)), 20, 20)
+	return t
+}
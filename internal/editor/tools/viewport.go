@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"image"
+	"math"
+)
+
+// Zoom limits, as multiples of the image's native size
+const (
+	MinZoom = 0.1
+	MaxZoom = 8.0
+)
+
+// Viewport maps between image pixels and the logical coordinates of the
+// area the image is shown in. Scale is logical units per image pixel, and
+// OffsetX, OffsetY is where the image's origin appears.
+type Viewport struct {
+	Scale            float64
+	OffsetX, OffsetY float64
+}
+
+// FitViewport returns a viewport that shows the whole image as large as
+// possible within a view of the given size, centred and without distorting
+// its aspect ratio. The image is never enlarged beyond maxScale.
+func FitViewport(imgSize image.Point, viewW, viewH, maxScale float64) Viewport {
+	if imgSize.X <= 0 || imgSize.Y <= 0 || viewW <= 0 || viewH <= 0 {
+		return Viewport{Scale: maxScale}
+	}
+	scale := math.Min(viewW/float64(imgSize.X), viewH/float64(imgSize.Y))
+	scale = math.Min(scale, maxScale)
+	return Viewport{
+		Scale:   scale,
+		OffsetX: (viewW - float64(imgSize.X)*scale) / 2,
+		OffsetY: (viewH - float64(imgSize.Y)*scale) / 2,
+	}
+}
+
+// ToImage maps a point in view coordinates to the image pixel beneath it
+func (v Viewport) ToImage(x, y float64) image.Point {
+	return image.Pt(
+		int(math.Floor((x-v.OffsetX)/v.Scale)),
+		int(math.Floor((y-v.OffsetY)/v.Scale)),
+	)
+}
+
+// ToView maps an image point to view coordinates
+func (v Viewport) ToView(p image.Point) (x, y float64) {
+	return float64(p.X)*v.Scale + v.OffsetX, float64(p.Y)*v.Scale + v.OffsetY
+}
+
+// ZoomAt returns the viewport rescaled to scale while keeping the image
+// point under view position (x, y) in place, as when zooming at the cursor
+func (v Viewport) ZoomAt(scale, x, y float64) Viewport {
+	ix := (x - v.OffsetX) / v.Scale
+	iy := (y - v.OffsetY) / v.Scale
+	return Viewport{
+		Scale:   scale,
+		OffsetX: x - ix*scale,
+		OffsetY: y - iy*scale,
+	}
+}
+
+// Pan returns the viewport with the image moved by dx, dy view units
+func (v Viewport) Pan(dx, dy float64) Viewport {
+	v.OffsetX += dx
+	v.OffsetY += dy
+	return v
+}
@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestFitViewport(t *testing.T) {
+	tests := []struct {
+		name         string
+		img          image.Point
+		viewW, viewH float64
+		want         Viewport
+	}{
+		{"wide image letterboxed", image.Pt(400, 100), 200, 200, Viewport{Scale: 0.5, OffsetX: 0, OffsetY: 75}},
+		{"tall image pillarboxed", image.Pt(100, 400), 200, 200, Viewport{Scale: 0.5, OffsetX: 75, OffsetY: 0}},
+		{"small image not enlarged", image.Pt(50, 50), 200, 100, Viewport{Scale: 1, OffsetX: 75, OffsetY: 25}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FitViewport(tt.img, tt.viewW, tt.viewH, 1); got != tt.want {
+				t.Errorf("FitViewport() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViewportRoundTrip(t *testing.T) {
+	// Mapping must stay exact at every zoom, including retina-style scales
+	for _, scale := range []float64{0.1, 0.25, 0.5, 1, 1.5, 2, 3, 8} {
+		v := Viewport{Scale: scale, OffsetX: 13.25, OffsetY: -7.5}
+		for _, p := range []image.Point{{0, 0}, {1, 1}, {37, 211}, {1023, 767}} {
+			x, y := v.ToView(p)
+			// Aim for the middle of the pixel, as a cursor over it would be
+			x += scale / 2
+			y += scale / 2
+			if got := v.ToImage(x, y); got != p {
+				t.Errorf("Scale %v: ToImage(ToView(%v)) = %v", scale, p, got)
+			}
+		}
+	}
+}
+
+func TestViewportZoomAtKeepsPointFixed(t *testing.T) {
+	v := Viewport{Scale: 0.5, OffsetX: 10, OffsetY: 20}
+	cursorX, cursorY := 110.0, 70.0
+	before := v.ToImage(cursorX, cursorY)
+
+	zoomed := v.ZoomAt(2, cursorX, cursorY)
+	if zoomed.Scale != 2 {
+		t.Errorf("Scale = %v, want 2", zoomed.Scale)
+	}
+	ix := (cursorX - zoomed.OffsetX) / zoomed.Scale
+	iy := (cursorY - zoomed.OffsetY) / zoomed.Scale
+	if math.Abs(ix-200) > 1e-9 || math.Abs(iy-100) > 1e-9 {
+		t.Errorf("Image point under cursor moved from %v to (%v, %v)", before, ix, iy)
+	}
+}
+
+func TestViewportPan(t *testing.T) {
+	v := Viewport{Scale: 2, OffsetX: 5, OffsetY: 5}.Pan(-15, 10)
+	if v.OffsetX != -10 || v.OffsetY != 15 || v.Scale != 2 {
+		t.Errorf("Pan() = %+v, want offset (-10, 15) at scale 2", v)
+	}
+}
@@ -0,0 +1,76 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/owenrumney/schnappit/internal/config"
+	"github.com/owenrumney/schnappit/internal/output"
+)
+
+// upload sends the annotated screenshot to the configured upload URL and
+// copies the link it returns to the clipboard. The upload runs in the
+// background behind a dialog that can cancel it; the editor closes once
+// the link is copied.
+func (e *Editor) upload() {
+	if e.cfg.Upload.URL == "" {
+		dialog.ShowError(fmt.Errorf("no upload URL is configured; add an \"upload\" section to %s", config.Path()), e.window)
+		return
+	}
+
+	// The upload reads a copy, as the editor redraws its overlay while open
+	finalImg := cloneImage(e.renderFinal())
+	format := e.exportFormat()
+	_, filename := e.defaultSavePath(format.Extension())
+	if filename == "" {
+		filename = "schnappit" + format.Extension()
+	}
+	uploader := uploaderFor(e.cfg.Upload)
+	opts := output.EncodeOptions{Format: format, Quality: e.cfg.JPEGQuality}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := dialog.NewCustom("Uploading…", "Cancel", widget.NewProgressBarInfinite(), e.window)
+	progress.SetOnClosed(cancel)
+	progress.Show()
+
+	go func() {
+		link, err := uploader.Upload(ctx, finalImg, filename, opts)
+		fyne.Do(func() {
+			progress.Hide()
+			// The clipboard is written from the main thread
+			if err == nil {
+				err = output.CopyText(link)
+			}
+			switch {
+			case errors.Is(err, context.Canceled):
+				return
+			case err != nil:
+				dialog.ShowError(err, e.window)
+				return
+			}
+			log.Printf("Uploaded capture to %s", link)
+			fyne.CurrentApp().SendNotification(fyne.NewNotification("Schnappit", "Link copied: "+link))
+			e.archive(finalImg)
+			e.window.Close()
+		})
+	}()
+}
+
+// uploaderFor builds an uploader from the upload config
+func uploaderFor(c config.Upload) output.Uploader {
+	return output.Uploader{
+		URL:         c.URL,
+		Method:      c.Method,
+		Body:        c.Body,
+		Field:       c.Field,
+		Fields:      c.Fields,
+		Headers:     c.Headers,
+		ResponseURL: c.ResponseURL,
+	}
+}
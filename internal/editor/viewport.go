@@ -0,0 +1,161 @@
+package editor
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+)
+
+// maxInitialWindow caps the editor window's opening size, so that large
+// captures open fitted to a window that stays on screen
+var maxInitialWindow = fyne.NewSize(1280, 800)
+
+// toolbarHeight is the room left above the image for the toolbar when
+// sizing the window
+const toolbarHeight = 50
+
+// zoomStep is the factor the zoom changes by for each zoom in or out
+const zoomStep = 1.25
+
+// scrollZoomRate is how much each unit of Ctrl+scroll changes the zoom
+const scrollZoomRate = 1.01
+
+// nativeScale returns the view scale at which one image pixel fills one
+// screen pixel
+func (e *Editor) nativeScale() float64 {
+	return 1 / e.scaleFactor
+}
+
+// zoomPercent returns the current zoom relative to the image's native size
+func (e *Editor) zoomPercent() float64 {
+	return e.view.Scale / e.nativeScale() * 100
+}
+
+// toImage maps a position in the drawing area to an image pixel
+func (e *Editor) toImage(pos fyne.Position) image.Point {
+	return e.view.ToImage(float64(pos.X), float64(pos.Y))
+}
+
+// imagePixels converts a length in screen units to image pixels at the
+// current zoom, so handles and outlines keep a constant on-screen size
+func (e *Editor) imagePixels(v float64) int {
+	return int(v / e.view.Scale)
+}
+
+// layoutImage positions the screenshot within a drawing area of the given
+// size, refitting it first when fit-to-window is on
+func (e *Editor) layoutImage(size fyne.Size) {
+	e.viewSize = size
+	b := e.screenshot.Bounds()
+	if e.fitToWindow {
+		e.view = tools.FitViewport(b.Size(), float64(size.Width), float64(size.Height), e.nativeScale())
+	}
+
+	x, y := e.view.ToView(image.Point{})
+	e.imgCanvas.Move(fyne.NewPos(float32(x), float32(y)))
+	e.imgCanvas.Resize(fyne.NewSize(float32(float64(b.Dx())*e.view.Scale), float32(float64(b.Dy())*e.view.Scale)))
+
+	// Show individual pixels when zoomed in rather than blurring them
+	scaleMode := canvas.ImageScaleSmooth
+	if e.view.Scale > e.nativeScale() {
+		scaleMode = canvas.ImageScalePixels
+	}
+	if e.imgCanvas.ScaleMode != scaleMode {
+		e.imgCanvas.ScaleMode = scaleMode
+		e.imgCanvas.Refresh()
+	}
+
+	if e.zoomBtn != nil {
+		if text := fmt.Sprintf("%.0f%%", e.zoomPercent()); e.zoomBtn.Text != text {
+			e.zoomBtn.SetText(text)
+		}
+	}
+}
+
+// refreshView re-lays out the screenshot after the view has changed
+func (e *Editor) refreshView() {
+	e.layoutImage(e.viewSize)
+	canvas.Refresh(e.imgCanvas)
+}
+
+// setZoom zooms to scale, keeping the image point under view position x, y
+// in place
+func (e *Editor) setZoom(scale, x, y float64) {
+	scale = math.Max(tools.MinZoom*e.nativeScale(), math.Min(tools.MaxZoom*e.nativeScale(), scale))
+	e.fitToWindow = false
+	e.view = e.view.ZoomAt(scale, x, y)
+	e.refreshView()
+}
+
+// zoomBy changes the zoom by factor around the centre of the view
+func (e *Editor) zoomBy(factor float64) {
+	e.setZoom(e.view.Scale*factor, float64(e.viewSize.Width)/2, float64(e.viewSize.Height)/2)
+}
+
+// zoomToPercent zooms to a percentage of the image's native size around
+// the centre of the view
+func (e *Editor) zoomToPercent(percent float64) {
+	e.setZoom(e.nativeScale()*percent/100, float64(e.viewSize.Width)/2, float64(e.viewSize.Height)/2)
+}
+
+// zoomToFit fits the whole image in the window, and keeps it fitted as the
+// window is resized
+func (e *Editor) zoomToFit() {
+	e.fitToWindow = true
+	e.refreshView()
+}
+
+// pan moves the image by dx, dy screen units
+func (e *Editor) pan(dx, dy float64) {
+	e.fitToWindow = false
+	e.view = e.view.Pan(dx, dy)
+	e.refreshView()
+}
+
+// showZoomMenu pops up the zoom presets below anchor
+func (e *Editor) showZoomMenu(anchor fyne.CanvasObject) {
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("Fit to Window", e.zoomToFit),
+		fyne.NewMenuItem("Actual Size (100%)", func() { e.zoomToPercent(100) }),
+		fyne.NewMenuItem("200%", func() { e.zoomToPercent(200) }),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Zoom In", func() { e.zoomBy(zoomStep) }),
+		fyne.NewMenuItem("Zoom Out", func() { e.zoomBy(1 / zoomStep) }),
+	)
+
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(anchor)
+	widget.ShowPopUpMenuAtPosition(menu, e.window.Canvas(), pos.Add(fyne.NewPos(0, anchor.Size().Height)))
+}
+
+// pinchZoom zooms by a trackpad pinch, where magnification is the change
+// in size it asks for, such as 0.05 for 5% larger. The zoom centres on pos,
+// a position in the window, when it is over the image, or else on the
+// centre of the view.
+func (e *Editor) pinchZoom(magnification float64, pos fyne.Position) {
+	origin := fyne.CurrentApp().Driver().AbsolutePositionForObject(e.drawArea)
+	local := pos.Subtract(origin)
+	size := e.drawArea.Size()
+	if local.X < 0 || local.Y < 0 || local.X > size.Width || local.Y > size.Height {
+		e.zoomBy(1 + magnification)
+		return
+	}
+	e.setZoom(e.view.Scale*(1+magnification), float64(local.X), float64(local.Y))
+}
+
+// Scrolled implements fyne.Scrollable. Scrolling with Ctrl or Cmd held
+// zooms at the cursor; otherwise it pans.
+func (d *drawArea) Scrolled(ev *fyne.ScrollEvent) {
+	e := d.editor
+	if e.zoomModifierDown {
+		factor := math.Pow(scrollZoomRate, float64(ev.Scrolled.DY))
+		e.setZoom(e.view.Scale*factor, float64(ev.Position.X), float64(ev.Position.Y))
+		return
+	}
+	e.pan(float64(ev.Scrolled.DX), float64(ev.Scrolled.DY))
+}
@@ -0,0 +1,46 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// DefaultArchiveDir is where copies of exported images are kept by default
+const DefaultArchiveDir = "~/" + DefaultDir + "/archive"
+
+// maxArchiveAttempts limits how many numbered names are tried when the
+// archive already holds a file with the template's name
+const maxArchiveAttempts = 100
+
+// Archive saves a PNG copy of an exported image, named by naming, and
+// returns its path. Existing files are never overwritten, even by another
+// save racing this one; a number is added to the name instead.
+func Archive(img image.Image, naming Naming, info CaptureInfo) (string, error) {
+	path, err := naming.Path(info, FormatPNG.Extension())
+	if err != nil {
+		return "", err
+	}
+
+	encode := func(w io.Writer) error {
+		return Encode(w, img, EncodeOptions{Format: FormatPNG})
+	}
+	base := strings.TrimSuffix(path, FormatPNG.Extension())
+	for i := 2; ; i++ {
+		err := writeFile(path, os.O_EXCL, encode)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+		if i > maxArchiveAttempts {
+			return "", fmt.Errorf("failed to archive image: %s already exists", path)
+		}
+		path = fmt.Sprintf("%s-%d%s", base, i, FormatPNG.Extension())
+	}
+}
@@ -0,0 +1,77 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	naming := Naming{Dir: dir, Filename: "{yyyy}/shot-{time}"}
+	info := CaptureInfo{Time: time.Date(2024, 1, 15, 14, 30, 52, 0, time.Local)}
+
+	first, err := Archive(testImage(), naming, info)
+	if err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	if want := filepath.Join(dir, "2024", "shot-143052.png"); first != want {
+		t.Errorf("Archive() = %q, want %q", first, want)
+	}
+
+	// A second export in the same second gets its own file
+	second, err := Archive(testImage(), naming, info)
+	if err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+	if want := filepath.Join(dir, "2024", "shot-143052-2.png"); second != want {
+		t.Errorf("second Archive() = %q, want %q", second, want)
+	}
+
+	for _, path := range []string{first, second} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != FilePermissions {
+			t.Errorf("%s has permissions %v, want %v", path, fi.Mode().Perm(), os.FileMode(FilePermissions))
+		}
+	}
+}
+
+func TestArchiveInvalidNaming(t *testing.T) {
+	if _, err := Archive(testImage(), Naming{Dir: t.TempDir(), Filename: "../out"}, CaptureInfo{Time: time.Now()}); err == nil {
+		t.Error("Archive() should reject a name outside the archive")
+	}
+}
+
+func TestArchiveConcurrent(t *testing.T) {
+	naming := Naming{Dir: t.TempDir(), Filename: "shot-{time}"}
+	info := CaptureInfo{Time: time.Date(2024, 1, 15, 14, 30, 52, 0, time.Local)}
+
+	// Saves racing for the same name each get a file of their own
+	const saves = 8
+	paths := make(chan string, saves)
+	var wg sync.WaitGroup
+	for range saves {
+		wg.Go(func() {
+			path, err := Archive(testImage(), naming, info)
+			if err != nil {
+				t.Errorf("Archive() error = %v", err)
+			}
+			paths <- path
+		})
+	}
+	wg.Wait()
+	close(paths)
+
+	seen := map[string]bool{}
+	for path := range paths {
+		if seen[path] {
+			t.Errorf("%s was written twice", path)
+		}
+		seen[path] = true
+	}
+}
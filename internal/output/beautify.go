@@ -0,0 +1,153 @@
+package output
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Frame describes the decoration Beautify adds around an image. Sizes are
+// in image pixels.
+type Frame struct {
+	Padding      int         // Space between the image and the edge of the frame
+	Background   color.Color // Nil leaves the background transparent
+	GradientEnd  color.Color // If set, the background fades diagonally from Background to this
+	CornerRadius int         // Rounds the corners of the image
+
+	ShadowBlur    int     // Softness of the drop shadow; zero disables it
+	ShadowOpacity float64 // Darkness of the drop shadow, from 0 to 1
+}
+
+// Beautify returns img framed with padding, a background, rounded corners
+// and a drop shadow as described by f
+func Beautify(img image.Image, f Frame) *image.RGBA {
+	b := img.Bounds()
+	pad := max(0, f.Padding)
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx()+2*pad, b.Dy()+2*pad))
+	inner := image.Rect(pad, pad, pad+b.Dx(), pad+b.Dy())
+
+	fillBackground(out, f.Background, f.GradientEnd)
+
+	radius := min(max(0, f.CornerRadius), b.Dx()/2, b.Dy()/2)
+	mask := roundedRectMask(out.Bounds(), inner, radius)
+
+	if f.ShadowBlur > 0 && f.ShadowOpacity > 0 {
+		// The shadow falls slightly below the image, as if lit from above
+		offset := f.ShadowBlur / 3
+		shadow := image.NewAlpha(out.Bounds())
+		draw.Draw(shadow, shadow.Bounds().Add(image.Pt(0, offset)), mask, image.Point{}, draw.Src)
+		blurAlpha(shadow, f.ShadowBlur)
+
+		tint := image.NewUniform(color.NRGBA{A: uint8(math.Round(math.Min(f.ShadowOpacity, 1) * 255))})
+		draw.DrawMask(out, out.Bounds(), tint, image.Point{}, shadow, image.Point{}, draw.Over)
+	}
+
+	draw.DrawMask(out, inner, img, b.Min, mask, inner.Min, draw.Over)
+	return out
+}
+
+// fillBackground fills img with from, fading diagonally to the colour to
+// when it is set
+func fillBackground(img *image.RGBA, from, to color.Color) {
+	if from == nil {
+		return
+	}
+	if to == nil {
+		draw.Draw(img, img.Bounds(), image.NewUniform(from), image.Point{}, draw.Src)
+		return
+	}
+
+	r0, g0, b0, a0 := from.RGBA()
+	r1, g1, b1, a1 := to.RGBA()
+	lerp := func(v0, v1 uint32, t float64) uint8 {
+		return uint8((float64(v0)+(float64(v1)-float64(v0))*t)/257 + 0.5)
+	}
+
+	bounds := img.Bounds()
+	span := float64(max(1, bounds.Dx()+bounds.Dy()-2))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			t := float64(x-bounds.Min.X+y-bounds.Min.Y) / span
+			img.SetRGBA(x, y, color.RGBA{
+				R: lerp(r0, r1, t),
+				G: lerp(g0, g1, t),
+				B: lerp(b0, b1, t),
+				A: lerp(a0, a1, t),
+			})
+		}
+	}
+}
+
+// roundedRectMask returns a mask covering r with its corners rounded to
+// radius, anti-aliased along the curves
+func roundedRectMask(bounds, r image.Rectangle, radius int) *image.Alpha {
+	mask := image.NewAlpha(bounds)
+	draw.Draw(mask, r, image.Opaque, image.Point{}, draw.Src)
+	if radius <= 0 {
+		return mask
+	}
+
+	rad := float64(radius)
+	corners := []struct {
+		area   image.Rectangle
+		cx, cy float64
+	}{
+		{image.Rect(r.Min.X, r.Min.Y, r.Min.X+radius, r.Min.Y+radius), float64(r.Min.X) + rad, float64(r.Min.Y) + rad},
+		{image.Rect(r.Max.X-radius, r.Min.Y, r.Max.X, r.Min.Y+radius), float64(r.Max.X) - rad, float64(r.Min.Y) + rad},
+		{image.Rect(r.Min.X, r.Max.Y-radius, r.Min.X+radius, r.Max.Y), float64(r.Min.X) + rad, float64(r.Max.Y) - rad},
+		{image.Rect(r.Max.X-radius, r.Max.Y-radius, r.Max.X, r.Max.Y), float64(r.Max.X) - rad, float64(r.Max.Y) - rad},
+	}
+	for _, c := range corners {
+		for y := c.area.Min.Y; y < c.area.Max.Y; y++ {
+			for x := c.area.Min.X; x < c.area.Max.X; x++ {
+				d := math.Hypot(float64(x)+0.5-c.cx, float64(y)+0.5-c.cy)
+				coverage := math.Max(0, math.Min(1, rad-d+0.5))
+				mask.SetAlpha(x, y, color.Alpha{A: uint8(coverage*255 + 0.5)})
+			}
+		}
+	}
+	return mask
+}
+
+// blurAlpha softens m in place with three passes of a box blur, which
+// approximates a Gaussian blur of the given radius
+func blurAlpha(m *image.Alpha, radius int) {
+	box := max(1, radius/3)
+	w, h := m.Rect.Dx(), m.Rect.Dy()
+	line := make([]uint8, max(w, h))
+
+	for range 3 {
+		for y := range h {
+			row := m.Pix[y*m.Stride : y*m.Stride+w]
+			boxBlur(row, 1, w, box, line)
+		}
+		for x := range w {
+			boxBlur(m.Pix[x:], m.Stride, h, box, line)
+		}
+	}
+}
+
+// boxBlur averages n values spaced stride apart in pix over a window of
+// box on each side, treating values beyond the ends as zero. scratch must
+// hold at least n values.
+func boxBlur(pix []uint8, stride, n, box int, scratch []uint8) {
+	for i := range n {
+		scratch[i] = pix[i*stride]
+	}
+
+	window := 2*box + 1
+	sum := 0
+	for i := 0; i < box && i < n; i++ {
+		sum += int(scratch[i])
+	}
+	for i := range n {
+		if j := i + box; j < n {
+			sum += int(scratch[j])
+		}
+		if j := i - box - 1; j >= 0 {
+			sum -= int(scratch[j])
+		}
+		pix[i*stride] = uint8((sum + window/2) / window)
+	}
+}
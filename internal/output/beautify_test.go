@@ -0,0 +1,71 @@
+package output
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestBeautify(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 60))
+	red := color.RGBA{R: 255, A: 255}
+	draw.Draw(img, img.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	t.Run("padding and background", func(t *testing.T) {
+		out := Beautify(img, Frame{Padding: 20, Background: white})
+		if got, want := out.Bounds(), image.Rect(0, 0, 140, 100); got != want {
+			t.Fatalf("Bounds() = %v, want %v", got, want)
+		}
+		if got := out.RGBAAt(5, 5); got != white {
+			t.Errorf("padding pixel = %v, want %v", got, white)
+		}
+		if got := out.RGBAAt(20, 20); got != red {
+			t.Errorf("image corner pixel = %v, want %v", got, red)
+		}
+	})
+
+	t.Run("rounded corners", func(t *testing.T) {
+		out := Beautify(img, Frame{Padding: 10, Background: white, CornerRadius: 12})
+		if got := out.RGBAAt(10, 10); got != white {
+			t.Errorf("rounded corner pixel = %v, want background %v", got, white)
+		}
+		if got := out.RGBAAt(60, 40); got != red {
+			t.Errorf("centre pixel = %v, want %v", got, red)
+		}
+		if got := out.RGBAAt(10, 40); got != red {
+			t.Errorf("edge pixel = %v, want %v", got, red)
+		}
+	})
+
+	t.Run("gradient", func(t *testing.T) {
+		black := color.RGBA{A: 255}
+		out := Beautify(img, Frame{Padding: 10, Background: black, GradientEnd: white})
+		if got := out.RGBAAt(0, 0); got != black {
+			t.Errorf("top left = %v, want %v", got, black)
+		}
+		if got := out.RGBAAt(119, 79); got != white {
+			t.Errorf("bottom right = %v, want %v", got, white)
+		}
+	})
+
+	t.Run("shadow", func(t *testing.T) {
+		out := Beautify(img, Frame{Padding: 30, Background: white, ShadowBlur: 24, ShadowOpacity: 0.5})
+		below := out.RGBAAt(80, 95)
+		above := out.RGBAAt(80, 24)
+		if below.R >= above.R {
+			t.Errorf("shadow below image (%v) should be darker than above it (%v)", below, above)
+		}
+		if got := out.RGBAAt(0, 0); got != white {
+			t.Errorf("far corner = %v, want unshadowed %v", got, white)
+		}
+	})
+
+	t.Run("transparent background", func(t *testing.T) {
+		out := Beautify(img, Frame{Padding: 8})
+		if got := out.RGBAAt(2, 2); got.A != 0 {
+			t.Errorf("padding alpha = %d, want 0", got.A)
+		}
+	})
+}
@@ -2,38 +2,182 @@ package output
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"image"
-	"image/png"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// ClipboardPayload is one of the representations of a capture that can be
+// placed on the clipboard together
+type ClipboardPayload string
 
-	"golang.design/x/clipboard"
+const (
+	PayloadImage    ClipboardPayload = "image"    // The image as PNG
+	PayloadPath     ClipboardPayload = "path"     // The saved copy's file path, as text
+	PayloadFileURI  ClipboardPayload = "file_uri" // A file:// URI for the saved copy
+	PayloadMarkdown ClipboardPayload = "markdown" // ![](path) for the saved copy
+	PayloadHTML     ClipboardPayload = "html"     // <img> with the image in a data URI
 )
 
-var clipboardInitialized bool
+// Clipboard type identifiers. Other platforms place only the image, or
+// failing that the plain text.
+const (
+	typePNG      = "public.png"
+	typeText     = "public.utf8-plain-text"
+	typeHTML     = "public.html"
+	typeFileURL  = "public.file-url"
+	typeMarkdown = "net.daringfireball.markdown"
+)
+
+// ClipboardPayloads returns every payload kind, in the order they are offered
+func ClipboardPayloads() []ClipboardPayload {
+	return []ClipboardPayload{PayloadImage, PayloadPath, PayloadFileURI, PayloadMarkdown, PayloadHTML}
+}
 
-// initClipboard initializes the clipboard (must be called from main thread)
-func initClipboard() error {
-	if clipboardInitialized {
-		return nil
+// Name returns the payload kind's description for menus
+func (p ClipboardPayload) Name() string {
+	switch p {
+	case PayloadImage:
+		return "Image"
+	case PayloadPath:
+		return "File Path"
+	case PayloadFileURI:
+		return "File URI"
+	case PayloadMarkdown:
+		return "Markdown"
+	case PayloadHTML:
+		return "HTML"
 	}
-	if err := clipboard.Init(); err != nil {
-		return fmt.Errorf("failed to initialize clipboard: %w", err)
+	return string(p)
+}
+
+// NeedsFile reports whether the payload refers to a saved copy of the image
+func (p ClipboardPayload) NeedsFile() bool {
+	return p == PayloadPath || p == PayloadFileURI || p == PayloadMarkdown
+}
+
+// ParseClipboardPayload parses a payload kind such as "markdown"
+func ParseClipboardPayload(s string) (ClipboardPayload, error) {
+	for _, p := range ClipboardPayloads() {
+		if ClipboardPayload(strings.ToLower(strings.TrimSpace(s))) == p {
+			return p, nil
+		}
 	}
-	clipboardInitialized = true
-	return nil
+	return "", fmt.Errorf("unknown clipboard payload %q", s)
+}
+
+// clipboardItem is one representation on the clipboard, by type identifier
+type clipboardItem struct {
+	kind string
+	data []byte
 }
 
 // CopyToClipboard copies the image to the system clipboard
 func CopyToClipboard(img image.Image) error {
-	if err := initClipboard(); err != nil {
+	return CopyPayloads(img, "", 1, []ClipboardPayload{PayloadImage})
+}
+
+// CopyText copies plain text, such as a link, to the system clipboard
+func CopyText(text string) error {
+	return writeClipboard([]clipboardItem{{typeText, []byte(text)}})
+}
+
+// CopyPayloads places several representations of a capture on the system
+// clipboard at once. path is the saved copy that the path, file URI and
+// Markdown payloads refer to. scaleFactor is the capture's physical pixels
+// per point, so HTML shows Retina captures at their size on screen.
+func CopyPayloads(img image.Image, path string, scaleFactor float64, payloads []ClipboardPayload) error {
+	items, err := clipboardItems(img, path, scaleFactor, payloads)
+	if err != nil {
 		return err
 	}
+	if len(items) == 0 {
+		return fmt.Errorf("nothing selected to copy")
+	}
+	return writeClipboard(items)
+}
 
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, img); err != nil {
-		return fmt.Errorf("failed to encode image: %w", err)
+// clipboardItems builds the clipboard representations for the payloads.
+// Only one plain text representation is possible, so Markdown takes
+// precedence over the path, which takes precedence over the URI.
+func clipboardItems(img image.Image, path string, scaleFactor float64, payloads []ClipboardPayload) ([]clipboardItem, error) {
+	selected := make(map[ClipboardPayload]bool)
+	for _, p := range payloads {
+		if p.NeedsFile() && path == "" {
+			return nil, fmt.Errorf("%s needs a saved copy of the image", p.Name())
+		}
+		selected[p] = true
 	}
 
-	clipboard.Write(clipboard.FmtImage, buf.Bytes())
-	return nil
+	var uri string
+	if path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path: %w", err)
+		}
+		path = abs
+		uri = (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+	}
+
+	var pngData []byte
+	if selected[PayloadImage] || selected[PayloadHTML] {
+		// The clipboard's image format is always PNG, whatever the export format
+		var buf bytes.Buffer
+		if err := Encode(&buf, img, EncodeOptions{Format: FormatPNG}); err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+		pngData = buf.Bytes()
+	}
+
+	var items []clipboardItem
+	if selected[PayloadImage] {
+		items = append(items, clipboardItem{typePNG, pngData})
+	}
+	if selected[PayloadFileURI] {
+		items = append(items, clipboardItem{typeFileURL, []byte(uri)})
+	}
+	if selected[PayloadMarkdown] {
+		items = append(items, clipboardItem{typeMarkdown, []byte(markdownImage(path))})
+	}
+	if selected[PayloadHTML] {
+		items = append(items, clipboardItem{typeHTML, []byte(htmlImage(img, pngData, scaleFactor))})
+	}
+
+	switch {
+	case selected[PayloadMarkdown]:
+		items = append(items, clipboardItem{typeText, []byte(markdownImage(path))})
+	case selected[PayloadPath]:
+		items = append(items, clipboardItem{typeText, []byte(path)})
+	case selected[PayloadFileURI]:
+		items = append(items, clipboardItem{typeText, []byte(uri)})
+	}
+	return items, nil
+}
+
+// markdownImage returns a Markdown image referring to path, in angle
+// brackets if it contains characters that would end the link
+func markdownImage(path string) string {
+	path = filepath.ToSlash(path)
+	if strings.ContainsAny(path, " ()<>") {
+		path = "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(path) + ">"
+	}
+	return fmt.Sprintf("![](%s)", path)
+}
+
+// htmlImage returns an <img> element holding PNG data in a data URI. Its
+// size is in CSS pixels, which are points, so it's the image's size divided
+// by the scale factor.
+func htmlImage(img image.Image, pngData []byte, scaleFactor float64) string {
+	if scaleFactor <= 0 {
+		scaleFactor = 1
+	}
+	size := img.Bounds().Size()
+	width := max(1, int(math.Round(float64(size.X)/scaleFactor)))
+	height := max(1, int(math.Round(float64(size.Y)/scaleFactor)))
+	return fmt.Sprintf(`<img src="data:image/png;base64,%s" width="%d" height="%d" alt="Screenshot">`,
+		base64.StdEncoding.EncodeToString(pngData), width, height)
 }
@@ -0,0 +1,60 @@
+//go:build darwin && cgo
+
+package output
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework AppKit
+
+#import <AppKit/AppKit.h>
+#include <stdlib.h>
+
+// Replace the general pasteboard's contents with a single item holding each
+// of the given representations
+int writePasteboard(char **types, void **data, int *lengths, int count) {
+    @autoreleasepool {
+        NSPasteboardItem *item = [[[NSPasteboardItem alloc] init] autorelease];
+        for (int i = 0; i < count; i++) {
+            NSString *type = [NSString stringWithUTF8String:types[i]];
+            NSData *bytes = [NSData dataWithBytes:data[i] length:lengths[i]];
+            if (![item setData:bytes forType:type]) {
+                return -1;
+            }
+        }
+
+        NSPasteboard *pasteboard = [NSPasteboard generalPasteboard];
+        [pasteboard clearContents];
+        return [pasteboard writeObjects:@[item]] ? 0 : -2;
+    }
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// writeClipboard replaces the clipboard's contents with every item, so that
+// each application pastes the representation it understands best
+func writeClipboard(items []clipboardItem) error {
+	types := make([]*C.char, len(items))
+	data := make([]unsafe.Pointer, len(items))
+	lengths := make([]C.int, len(items))
+	for i, item := range items {
+		types[i] = C.CString(item.kind)
+		data[i] = C.CBytes(item.data)
+		lengths[i] = C.int(len(item.data))
+	}
+	defer func() {
+		for i := range items {
+			C.free(unsafe.Pointer(types[i]))
+			C.free(data[i])
+		}
+	}()
+
+	if rc := C.writePasteboard(&types[0], &data[0], &lengths[0], C.int(len(items))); rc != 0 {
+		return fmt.Errorf("failed to write to clipboard (error %d)", rc)
+	}
+	return nil
+}
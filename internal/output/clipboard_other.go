@@ -0,0 +1,43 @@
+//go:build !darwin || !cgo
+
+package output
+
+import (
+	"fmt"
+
+	"golang.design/x/clipboard"
+)
+
+var clipboardInitialized bool
+
+// initClipboard initializes the clipboard (must be called from main thread)
+func initClipboard() error {
+	if clipboardInitialized {
+		return nil
+	}
+	if err := clipboard.Init(); err != nil {
+		return fmt.Errorf("failed to initialize clipboard: %w", err)
+	}
+	clipboardInitialized = true
+	return nil
+}
+
+// writeClipboard replaces the clipboard's contents. Only one representation
+// can be written here, so the image is preferred over plain text.
+func writeClipboard(items []clipboardItem) error {
+	if err := initClipboard(); err != nil {
+		return err
+	}
+	for _, want := range []struct {
+		kind   string
+		format clipboard.Format
+	}{{typePNG, clipboard.FmtImage}, {typeText, clipboard.FmtText}} {
+		for _, item := range items {
+			if item.kind == want.kind {
+				clipboard.Write(want.format, item.data)
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("the selected clipboard formats aren't supported on this platform")
+}
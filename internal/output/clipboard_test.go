@@ -0,0 +1,90 @@
+package output
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func itemsByKind(items []clipboardItem) map[string]string {
+	byKind := make(map[string]string)
+	for _, item := range items {
+		byKind[item.kind] = string(item.data)
+	}
+	return byKind
+}
+
+func TestClipboardItems(t *testing.T) {
+	items, err := clipboardItems(testImage(), "/tmp/My Shots/shot.png", 2, ClipboardPayloads())
+	if err != nil {
+		t.Fatalf("clipboardItems() error = %v", err)
+	}
+	got := itemsByKind(items)
+
+	if _, err := png.Decode(strings.NewReader(got[typePNG])); err != nil {
+		t.Errorf("image item isn't a PNG: %v", err)
+	}
+	if want := "file:///tmp/My%20Shots/shot.png"; got[typeFileURL] != want {
+		t.Errorf("file URL = %q, want %q", got[typeFileURL], want)
+	}
+	if want := "![](</tmp/My Shots/shot.png>)"; got[typeMarkdown] != want || got[typeText] != want {
+		t.Errorf("Markdown = %q, text = %q, want %q for both", got[typeMarkdown], got[typeText], want)
+	}
+
+	// The 64x48 image is shown at its size in points
+	html := got[typeHTML]
+	prefix := `<img src="data:image/png;base64,`
+	if !strings.HasPrefix(html, prefix) || !strings.Contains(html, `width="32" height="24"`) {
+		t.Fatalf("HTML = %.80q", html)
+	}
+	data, err := base64.StdEncoding.DecodeString(html[len(prefix):strings.Index(html, `" width`)])
+	if err != nil {
+		t.Fatalf("data URI isn't base64: %v", err)
+	}
+	if !bytes.Equal(data, []byte(got[typePNG])) {
+		t.Error("data URI doesn't hold the PNG image")
+	}
+}
+
+func TestClipboardItemsTextPrecedence(t *testing.T) {
+	tests := []struct {
+		payloads []ClipboardPayload
+		want     string
+	}{
+		{[]ClipboardPayload{PayloadPath}, "/tmp/shot.png"},
+		{[]ClipboardPayload{PayloadFileURI}, "file:///tmp/shot.png"},
+		{[]ClipboardPayload{PayloadFileURI, PayloadPath}, "/tmp/shot.png"},
+		{[]ClipboardPayload{PayloadPath, PayloadMarkdown}, "![](/tmp/shot.png)"},
+		{[]ClipboardPayload{PayloadImage}, ""},
+	}
+	for _, tt := range tests {
+		items, err := clipboardItems(testImage(), "/tmp/shot.png", 1, tt.payloads)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := itemsByKind(items)[typeText]; got != tt.want {
+			t.Errorf("%v: text = %q, want %q", tt.payloads, got, tt.want)
+		}
+	}
+}
+
+func TestClipboardItemsNeedFile(t *testing.T) {
+	if _, err := clipboardItems(testImage(), "", 1, []ClipboardPayload{PayloadMarkdown}); err == nil {
+		t.Error("Markdown without a saved copy should fail")
+	}
+	items, err := clipboardItems(testImage(), "", 1, []ClipboardPayload{PayloadImage, PayloadHTML})
+	if err != nil || len(items) != 2 {
+		t.Errorf("clipboardItems(image, html) = %d items, %v", len(items), err)
+	}
+}
+
+func TestParseClipboardPayload(t *testing.T) {
+	if p, err := ParseClipboardPayload(" Markdown "); err != nil || p != PayloadMarkdown {
+		t.Errorf("ParseClipboardPayload(Markdown) = %q, %v", p, err)
+	}
+	if _, err := ParseClipboardPayload("rtf"); err == nil {
+		t.Error("ParseClipboardPayload(rtf) should fail")
+	}
+}
@@ -0,0 +1,173 @@
+package output
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"log"
+	"os"
+
+	"github.com/owenrumney/schnappit/internal/project"
+)
+
+// projectChunk is the type of the PNG chunk that carries an embedded
+// project. Lower case first and second letters make it ancillary and
+// private, so other software shows the image and ignores the chunk; the
+// upper case last letter marks it unsafe to copy, asking editors that
+// change the pixels to drop it.
+const projectChunk = "snAP"
+
+// pngSignature starts every PNG file
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngChunk is one chunk of a PNG file
+type pngChunk struct {
+	typ    string
+	data   []byte
+	offset int // Where the chunk starts in the file
+}
+
+// readChunks splits PNG data into its chunks, checking their lengths and
+// checksums. It stops at the end chunk, ignoring anything after it as
+// decoders do.
+func readChunks(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("not a PNG image")
+	}
+	var chunks []pngChunk
+	for pos := len(pngSignature); pos < len(data); {
+		if len(data)-pos < 12 {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		if length < 0 || length > len(data)-pos-12 {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		body := data[pos+4 : pos+8+length]
+		if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(data[pos+8+length:]) {
+			return nil, fmt.Errorf("corrupt PNG chunk %q", body[:4])
+		}
+		chunks = append(chunks, pngChunk{typ: string(body[:4]), data: body[4:], offset: pos})
+		if chunks[len(chunks)-1].typ == "IEND" {
+			break
+		}
+		pos += 12 + length
+	}
+	return chunks, nil
+}
+
+// pixelDigest hashes the compressed pixel data of a PNG, so an embedded
+// project can tell whether the image has been changed since it was written
+func pixelDigest(chunks []pngChunk) [sha256.Size]byte {
+	h := sha256.New()
+	for _, c := range chunks {
+		if c.typ == "IDAT" {
+			h.Write(c.data)
+		}
+	}
+	var digest [sha256.Size]byte
+	h.Sum(digest[:0])
+	return digest
+}
+
+// EmbedProject returns PNG data with the project stored in a private
+// chunk before the end of the image, replacing any project already there.
+// The image itself is unchanged, so it opens anywhere as a normal PNG.
+func EmbedProject(pngData []byte, p *project.Project) ([]byte, error) {
+	chunks, err := readChunks(pngData)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 || chunks[len(chunks)-1].typ != "IEND" {
+		return nil, errors.New("PNG image has no end chunk")
+	}
+
+	// The chunk holds a digest of the pixel data, then the project archive
+	digest := pixelDigest(chunks)
+	payload := bytes.NewBuffer(digest[:])
+	if err := project.Write(payload, p); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	for _, c := range chunks {
+		if c.typ == projectChunk {
+			continue
+		}
+		if c.typ == "IEND" {
+			writeChunk(&out, projectChunk, payload.Bytes())
+		}
+		writeChunk(&out, c.typ, c.data)
+	}
+	return out.Bytes(), nil
+}
+
+// writeChunk appends a PNG chunk with its length and checksum
+func writeChunk(buf *bytes.Buffer, typ string, data []byte) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], typ)
+	buf.Write(header[:])
+	buf.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	binary.Write(buf, binary.BigEndian, crc.Sum32())
+}
+
+// ExtractProject returns the project embedded in PNG data, or nil if there
+// isn't one. A project is also ignored if the pixels have been changed
+// since it was embedded, as its annotations would no longer match them.
+func ExtractProject(pngData []byte) (*project.Project, error) {
+	if !bytes.HasPrefix(pngData, pngSignature) {
+		return nil, nil
+	}
+	chunks, err := readChunks(pngData)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range chunks {
+		if c.typ != projectChunk {
+			continue
+		}
+		if len(c.data) < sha256.Size {
+			return nil, errors.New("embedded project is truncated")
+		}
+		if digest := pixelDigest(chunks); !bytes.Equal(c.data[:sha256.Size], digest[:]) {
+			return nil, nil
+		}
+		archive := c.data[sha256.Size:]
+		p, err := project.Read(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedded project: %w", err)
+		}
+		return p, nil
+	}
+	return nil, nil
+}
+
+// LoadImage reads an image from disk, along with the project embedded in it
+// if it is a PNG written with one. The project is nil otherwise, including
+// when it can't be read, as the image itself is still usable.
+func LoadImage(path string) (image.Image, *project.Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	p, err := ExtractProject(data)
+	if err != nil {
+		log.Printf("Ignoring the project embedded in %s: %v", path, err)
+		return img, nil, nil
+	}
+	return img, p, nil
+}
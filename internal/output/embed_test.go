@@ -0,0 +1,209 @@
+package output
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+	"github.com/owenrumney/schnappit/internal/project"
+)
+
+func testProject() *project.Project {
+	return &project.Project{
+		Screenshot: testImage(),
+		Annotations: []tools.Annotation{
+			tools.NewArrow(image.Pt(4, 40), image.Pt(40, 4), color.RGBA{R: 255, A: 255}, 3),
+		},
+		Metadata: project.Metadata{CapturedAt: time.Date(2024, 1, 15, 14, 30, 52, 0, time.UTC), ScaleFactor: 2},
+	}
+}
+
+func encodeWithProject(t *testing.T, img image.Image, p *project.Project) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, EncodeOptions{Format: FormatPNG, Project: p}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestEmbedProjectRoundTrip(t *testing.T) {
+	data := encodeWithProject(t, testImage(), testProject())
+
+	// The file still decodes as the plain image
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if !samePixels(img, testImage()) {
+		t.Error("embedding changed the image")
+	}
+
+	p, err := ExtractProject(data)
+	if err != nil || p == nil {
+		t.Fatalf("ExtractProject() = %v, %v", p, err)
+	}
+	if len(p.Annotations) != 1 || p.Metadata.ScaleFactor != 2 || !samePixels(p.Screenshot, testImage()) {
+		t.Errorf("ExtractProject() = %+v", p.Metadata)
+	}
+	if _, ok := p.Annotations[0].(*tools.ArrowAnnotation); !ok {
+		t.Errorf("annotation = %T, want an arrow", p.Annotations[0])
+	}
+}
+
+func TestEmbedProjectReplacesExisting(t *testing.T) {
+	data := encodeWithProject(t, testImage(), testProject())
+	other := testProject()
+	other.Annotations = nil
+
+	again, err := EmbedProject(data, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := readChunks(again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, c := range chunks {
+		if c.typ == projectChunk {
+			count++
+		}
+	}
+	if count != 1 || chunks[len(chunks)-1].typ != "IEND" {
+		t.Errorf("%d project chunks, last chunk %q; want one before IEND", count, chunks[len(chunks)-1].typ)
+	}
+	if p, _ := ExtractProject(again); p == nil || len(p.Annotations) != 0 {
+		t.Error("ExtractProject() should return the replacement project")
+	}
+}
+
+func TestExtractProjectIgnoresChangedPixels(t *testing.T) {
+	data := encodeWithProject(t, testImage(), testProject())
+	chunks, err := readChunks(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-encode different pixels but keep the project chunk, as an editor
+	// that copies unknown chunks regardless would
+	changed := testImage()
+	changed.SetRGBA(30, 30, color.RGBA{G: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, changed); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := readChunks(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	for _, c := range plain {
+		if c.typ == "IEND" {
+			for _, p := range chunks {
+				if p.typ == projectChunk {
+					writeChunk(&out, p.typ, p.data)
+				}
+			}
+		}
+		writeChunk(&out, c.typ, c.data)
+	}
+
+	if p, err := ExtractProject(out.Bytes()); p != nil || err != nil {
+		t.Errorf("ExtractProject() = %v, %v; want the stale project ignored", p, err)
+	}
+}
+
+func TestExtractProjectPlainImages(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := ExtractProject(buf.Bytes()); p != nil || err != nil {
+		t.Errorf("ExtractProject(plain PNG) = %v, %v", p, err)
+	}
+	if p, err := ExtractProject([]byte{0xff, 0xd8, 0xff}); p != nil || err != nil {
+		t.Errorf("ExtractProject(JPEG) = %v, %v", p, err)
+	}
+
+	corrupt := encodeWithProject(t, testImage(), testProject())
+	corrupt[len(corrupt)-20] ^= 0xff
+	if _, err := ExtractProject(corrupt); err == nil {
+		t.Error("ExtractProject() should report a corrupt chunk")
+	}
+}
+
+func TestLoadImageAndOptimizedEmbed(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "editable.png")
+	report, err := SaveOptimizedPNG(blocks(), path, OptimizeOptions{Project: testProject()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(path); err != nil || int(fi.Size()) != report.After {
+		t.Errorf("file size = %v, report.After = %d", fi, report.After)
+	}
+
+	img, p, err := LoadImage(path)
+	if err != nil {
+		t.Fatalf("LoadImage() error = %v", err)
+	}
+	if !samePixels(img, blocks()) || p == nil || len(p.Annotations) != 1 {
+		t.Errorf("LoadImage() = image %v, project %v", img.Bounds(), p)
+	}
+
+	plain := filepath.Join(dir, "plain.jpg")
+	if err := SaveToPath(blocks(), plain); err != nil {
+		t.Fatal(err)
+	}
+	if _, p, err := LoadImage(plain); err != nil || p != nil {
+		t.Errorf("LoadImage(jpeg) project = %v, %v", p, err)
+	}
+}
+
+func TestLoadImageToleratesBadChunks(t *testing.T) {
+	dir := t.TempDir()
+
+	// Bytes after the end chunk are ignored by decoders, and so here
+	data := append(encodeWithProject(t, testImage(), testProject()), "trailing junk"...)
+	trailing := filepath.Join(dir, "trailing.png")
+	if err := os.WriteFile(trailing, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if img, p, err := LoadImage(trailing); err != nil || p == nil || !samePixels(img, testImage()) {
+		t.Errorf("LoadImage(trailing data) = %v, %v", p, err)
+	}
+
+	// A project archive that can't be read still leaves the image
+	chunks, err := readChunks(encodeWithProject(t, testImage(), testProject()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	for _, c := range chunks {
+		if c.typ == projectChunk {
+			c.data = append(c.data[:sha256.Size:sha256.Size], "not a zip"...)
+		}
+		writeChunk(&out, c.typ, c.data)
+	}
+	damaged := filepath.Join(dir, "damaged.png")
+	if err := os.WriteFile(damaged, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	img, p, err := LoadImage(damaged)
+	if err != nil || p != nil {
+		t.Fatalf("LoadImage(damaged project) = %v, %v; want the image alone", p, err)
+	}
+	if !samePixels(img, testImage()) {
+		t.Error("LoadImage() changed the image")
+	}
+}
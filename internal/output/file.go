@@ -3,7 +3,6 @@ package output
 import (
 	"fmt"
 	"image"
-	"image/png"
 	"io"
 	"os"
 	"path/filepath"
@@ -25,18 +24,52 @@ func SaveToFile(img image.Image) (string, error) {
 
 // SaveToWriter encodes the image as PNG and writes it to the given writer
 func SaveToWriter(img image.Image, w io.Writer) error {
-	return png.Encode(w, img)
+	return Encode(w, img, EncodeOptions{Format: FormatPNG})
 }
 
-// SaveToPath saves the image as PNG to the specified file path
+// SaveToPath saves the image to the specified file path, in the format
+// named by its extension, or as PNG if the extension isn't recognised
 func SaveToPath(img image.Image, path string) error {
-	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, FilePermissions)
+	return SaveToPathWithOptions(img, path, EncodeOptions{})
+}
+
+// SaveToPathWithOptions saves the image to the specified file path. If
+// opts names no format, it is chosen by the file extension, falling back to
+// PNG.
+func SaveToPathWithOptions(img image.Image, path string, opts EncodeOptions) error {
+	if opts.Format == "" {
+		opts.Format = FormatPNG
+		if f, ok := FormatForPath(path); ok {
+			opts.Format = f
+		}
+	}
+	return writeFile(path, os.O_TRUNC, func(w io.Writer) error {
+		return Encode(w, img, opts)
+	})
+}
+
+// SaveOptimizedPNG saves the image to path as a PNG made as small as opts
+// allow, and reports the size before and after
+func SaveOptimizedPNG(img image.Image, path string, opts OptimizeOptions) (OptimizeReport, error) {
+	var report OptimizeReport
+	err := writeFile(path, os.O_TRUNC, func(w io.Writer) (err error) {
+		report, err = OptimizePNG(w, img, opts)
+		return err
+	})
+	return report, err
+}
+
+// writeFile creates path and writes it with encode, removing the file
+// again if it can't be written completely. flag is os.O_TRUNC to replace an
+// existing file, or os.O_EXCL to fail with fs.ErrExist instead.
+func writeFile(path string, flag int, encode func(io.Writer) error) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|flag, FilePermissions)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer file.Close()
 
-	if err := png.Encode(file, img); err != nil {
+	if err := encode(file); err != nil {
 		file.Close()
 		os.Remove(path)
 		return fmt.Errorf("failed to encode image: %w", err)
@@ -49,7 +82,8 @@ func SaveToPath(img image.Image, path string) error {
 	return nil
 }
 
-// SaveToFileWithName saves the image to a file with the specified name
+// SaveToFileWithName saves the image to a file with the specified name, in
+// the format named by its extension
 func SaveToFileWithName(img image.Image, filename string) (string, error) {
 	if strings.Contains(filename, "..") || strings.ContainsAny(filename, `/\`) {
 		return "", fmt.Errorf("invalid filename: path traversal not allowed")
@@ -61,57 +95,25 @@ func SaveToFileWithName(img image.Image, filename string) (string, error) {
 	}
 
 	outPath := filepath.Join(dir, filename)
-
-	absDir, err := filepath.Abs(dir)
-	if err != nil {
-		return "", fmt.Errorf("failed to resolve output directory: %w", err)
-	}
-	absPath, err := filepath.Abs(outPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to resolve output path: %w", err)
-	}
-	if !strings.HasPrefix(absPath, absDir+string(filepath.Separator)) {
-		return "", fmt.Errorf("invalid filename: path escapes output directory")
-	}
-
-	file, err := os.OpenFile(outPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, FilePermissions)
-	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
+	if err := checkWithin(dir, outPath); err != nil {
+		return "", err
 	}
 
-	defer file.Close()
-
-	if err := png.Encode(file, img); err != nil {
-		file.Close()
-		os.Remove(outPath)
-		return "", fmt.Errorf("failed to encode image: %w", err)
-	}
-	if err := file.Sync(); err != nil {
-		file.Close()
-		os.Remove(outPath)
-		return "", fmt.Errorf("failed to sync file: %w", err)
+	if err := SaveToPath(img, outPath); err != nil {
+		return "", err
 	}
 
 	return outPath, nil
 }
 
-// GetOutputDir returns the output directory, creating it if necessary
+// GetOutputDir returns the default output directory, creating it if necessary
 func GetOutputDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	dir := filepath.Join(home, DefaultDir)
-
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create output directory: %w", err)
-	}
-
-	return dir, nil
+	return DefaultNaming().OutputDir(CaptureInfo{Time: time.Now()})
 }
 
-// GenerateFilename generates a timestamp-based filename
+// GenerateFilename generates a timestamp-based filename using the default
+// template
 func GenerateFilename() string {
-	return fmt.Sprintf("schnappit-%s.png", time.Now().Format("2006-01-02-150405"))
+	name, _ := expand(DefaultFilenameTemplate, CaptureInfo{Time: time.Now()})
+	return name + ".png"
 }
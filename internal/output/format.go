@@ -0,0 +1,167 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+
+	"github.com/owenrumney/schnappit/internal/project"
+)
+
+// Format is an image file format that exports can be encoded in
+type Format string
+
+const (
+	FormatPNG  Format = "png"
+	FormatJPEG Format = "jpeg"
+	FormatGIF  Format = "gif"
+	FormatBMP  Format = "bmp"
+	FormatTIFF Format = "tiff"
+)
+
+// DefaultJPEGQuality is the JPEG quality used when none is given
+const DefaultJPEGQuality = 90
+
+// EncodeOptions controls how an image is encoded
+type EncodeOptions struct {
+	// Format is the format to encode in. When saving to a path, an empty
+	// format is chosen from the file extension; otherwise it means PNG.
+	Format Format
+
+	// Quality is the JPEG quality from 1 to 100, or zero for DefaultJPEGQuality
+	Quality int
+
+	// Project, if set, is embedded in PNGs so they can be opened again
+	// with their annotations editable. Other formats ignore it.
+	Project *project.Project
+}
+
+// formatInfo describes how to write a format
+type formatInfo struct {
+	name       string   // Shown in file dialogs
+	extensions []string // Without the dot; the first is used for new files
+	encode     func(w io.Writer, img image.Image, opts EncodeOptions) error
+}
+
+var formats = map[Format]formatInfo{
+	FormatPNG: {"PNG Image", []string{"png"}, func(w io.Writer, img image.Image, _ EncodeOptions) error {
+		return png.Encode(w, img)
+	}},
+	FormatJPEG: {"JPEG Image", []string{"jpg", "jpeg"}, func(w io.Writer, img image.Image, opts EncodeOptions) error {
+		quality := opts.Quality
+		if quality == 0 {
+			quality = DefaultJPEGQuality
+		}
+		// JPEG has no alpha channel, so transparent areas such as an
+		// unfilled beautify background would otherwise turn black
+		return jpeg.Encode(w, flatten(img, color.White), &jpeg.Options{Quality: min(max(quality, 1), 100)})
+	}},
+	FormatGIF: {"GIF Image", []string{"gif"}, func(w io.Writer, img image.Image, _ EncodeOptions) error {
+		return gif.Encode(w, img, &gif.Options{NumColors: 256, Drawer: draw.FloydSteinberg})
+	}},
+	FormatBMP: {"BMP Image", []string{"bmp"}, func(w io.Writer, img image.Image, _ EncodeOptions) error {
+		return bmp.Encode(w, img)
+	}},
+	FormatTIFF: {"TIFF Image", []string{"tif", "tiff"}, func(w io.Writer, img image.Image, _ EncodeOptions) error {
+		return tiff.Encode(w, img, &tiff.Options{Compression: tiff.Deflate, Predictor: true})
+	}},
+}
+
+// Formats returns every supported format, PNG first
+func Formats() []Format {
+	return []Format{FormatPNG, FormatJPEG, FormatGIF, FormatBMP, FormatTIFF}
+}
+
+// Name returns the format's description for file dialogs, e.g. "PNG Image"
+func (f Format) Name() string {
+	return formats[f].name
+}
+
+// Extensions returns the file extensions of the format, without dots
+func (f Format) Extensions() []string {
+	return formats[f].extensions
+}
+
+// Extension returns the extension new files of the format are given, e.g. ".png"
+func (f Format) Extension() string {
+	if exts := f.Extensions(); len(exts) > 0 {
+		return "." + exts[0]
+	}
+	return ""
+}
+
+// ParseFormat parses a format name or extension, such as "jpeg" or "jpg"
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "."))
+	for _, f := range Formats() {
+		if s == string(f) {
+			return f, nil
+		}
+		for _, ext := range f.Extensions() {
+			if s == ext {
+				return f, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unsupported image format %q", s)
+}
+
+// FormatForPath returns the format named by a path's extension
+func FormatForPath(path string) (Format, bool) {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return "", false
+	}
+	f, err := ParseFormat(ext)
+	return f, err == nil
+}
+
+// Encode writes img to w in the format given by opts
+func Encode(w io.Writer, img image.Image, opts EncodeOptions) error {
+	f := opts.Format
+	if f == "" {
+		f = FormatPNG
+	}
+	info, ok := formats[f]
+	if !ok {
+		return fmt.Errorf("unsupported image format %q", f)
+	}
+	if opts.Project == nil || f != FormatPNG {
+		return info.encode(w, img, opts)
+	}
+
+	var buf bytes.Buffer
+	if err := info.encode(&buf, img, opts); err != nil {
+		return err
+	}
+	data, err := EmbedProject(buf.Bytes(), opts.Project)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// flatten returns img composited over a solid background, or img itself
+// if it is already opaque
+func flatten(img image.Image, background color.Color) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+	b := img.Bounds()
+	out := image.NewRGBA(b)
+	draw.Draw(out, b, image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(out, b, img, b.Min, draw.Over)
+	return out
+}
@@ -0,0 +1,142 @@
+package output
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// testImage returns an image with a few blocks of colour, half transparent
+// in one corner
+func testImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for y := range 48 {
+		for x := range 64 {
+			c := color.RGBA{R: uint8(x * 4), G: uint8(y * 5), B: 120, A: 255}
+			if x < 8 && y < 8 {
+				c = color.RGBA{}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestEncodeFormats(t *testing.T) {
+	decoders := map[Format]func(*bytes.Reader) (image.Image, error){
+		FormatPNG:  func(r *bytes.Reader) (image.Image, error) { return png.Decode(r) },
+		FormatJPEG: func(r *bytes.Reader) (image.Image, error) { return jpeg.Decode(r) },
+		FormatGIF:  func(r *bytes.Reader) (image.Image, error) { return gif.Decode(r) },
+		FormatBMP:  func(r *bytes.Reader) (image.Image, error) { return bmp.Decode(r) },
+		FormatTIFF: func(r *bytes.Reader) (image.Image, error) { return tiff.Decode(r) },
+	}
+
+	for _, f := range Formats() {
+		t.Run(string(f), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, testImage(), EncodeOptions{Format: f}); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			img, err := decoders[f](bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("decoding %s output: %v", f, err)
+			}
+			if img.Bounds().Size() != image.Pt(64, 48) {
+				t.Errorf("decoded size = %v, want 64x48", img.Bounds().Size())
+			}
+		})
+	}
+}
+
+func TestEncodeJPEGFlattensTransparency(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, testImage(), EncodeOptions{Format: FormatJPEG}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := img.At(2, 2).RGBA(); r>>8 < 240 || g>>8 < 240 || b>>8 < 240 {
+		t.Errorf("transparent corner = %v, want white", img.At(2, 2))
+	}
+}
+
+func TestEncodeJPEGQuality(t *testing.T) {
+	var low, high bytes.Buffer
+	if err := Encode(&low, testImage(), EncodeOptions{Format: FormatJPEG, Quality: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Encode(&high, testImage(), EncodeOptions{Format: FormatJPEG, Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if low.Len() >= high.Len() {
+		t.Errorf("quality 10 gave %d bytes and quality 100 gave %d; want the lower quality smaller", low.Len(), high.Len())
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	tests := map[string]Format{
+		"shot.png":       FormatPNG,
+		"shot.JPG":       FormatJPEG,
+		"shot.jpeg":      FormatJPEG,
+		"shots.png/shot": "",
+		"shot.gif":       FormatGIF,
+		"shot.bmp":       FormatBMP,
+		"shot.tif":       FormatTIFF,
+		"shot.tiff":      FormatTIFF,
+		"shot":           "",
+		"shot.webp":      "",
+	}
+	for path, want := range tests {
+		got, ok := FormatForPath(path)
+		if got != want || ok != (want != "") {
+			t.Errorf("FormatForPath(%q) = %q, %v; want %q", path, got, ok, want)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"jpeg", "JPG", ".jpg"} {
+		if f, err := ParseFormat(s); err != nil || f != FormatJPEG {
+			t.Errorf("ParseFormat(%q) = %q, %v; want jpeg", s, f, err)
+		}
+	}
+	if _, err := ParseFormat("webp"); err == nil {
+		t.Error("ParseFormat(webp) should fail")
+	}
+}
+
+func TestSaveToPathUsesExtension(t *testing.T) {
+	dir := t.TempDir()
+	magic := map[string][]byte{
+		"out.jpg":  {0xff, 0xd8},
+		"out.gif":  []byte("GIF8"),
+		"out.bmp":  []byte("BM"),
+		"out.png":  []byte("\x89PNG"),
+		"out.tiff": []byte("II*\x00"),
+		"out.dat":  []byte("\x89PNG"),
+	}
+	for name, want := range magic {
+		path := filepath.Join(dir, name)
+		if err := SaveToPath(testImage(), path); err != nil {
+			t.Fatalf("SaveToPath(%s) error = %v", name, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(data, want) {
+			t.Errorf("%s starts with % x, want % x", name, data[:len(want)], want)
+		}
+	}
+}
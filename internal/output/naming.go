@@ -0,0 +1,330 @@
+package output
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// DefaultDirTemplate is the directory captures are saved to by default
+	DefaultDirTemplate = "~/" + DefaultDir
+	// DefaultFilenameTemplate names captures "schnappit-2006-01-02-150405"
+	DefaultFilenameTemplate = "schnappit-{date}-{time}"
+
+	// unknownToken stands in for a window title or app name that isn't
+	// known, so a template that uses one as a folder still has a name
+	unknownToken = "unknown"
+
+	// maxTokenLength caps how many characters a window title or app name
+	// contributes to a file name
+	maxTokenLength = 60
+
+	// seqMarker stands in for the sequence number until the directory it
+	// counts within is known
+	seqMarker = "\x00"
+)
+
+// CaptureInfo describes a capture, for filling in name templates. An
+// unknown app or title expands to "unknown".
+type CaptureInfo struct {
+	Time    time.Time
+	Display int    // Index of the captured display, from zero
+	App     string // Name of the frontmost application
+	Title   string // Title of its frontmost window
+}
+
+// Naming decides where captures are saved and what they are called, using
+// templates such as "~/Pictures/{yyyy}" and "{app}-{date}-{seq:3}".
+//
+// Tokens in braces are replaced with details of the capture:
+//
+//	{date}  2006-01-02     {time}  150405
+//	{yyyy} {yy} {MM} {dd}  {HH} {mm} {ss}
+//	{display}  the display number, from 1
+//	{title}    the window title
+//	{app}      the application name
+//	{seq}      a counter that starts at 1 each day; {seq:3} pads it to 001
+//
+// The file name template may contain "/" to save into subfolders, such as
+// "{yyyy}/{MM}/shot-{time}". Token values never contain path separators,
+// and the result must stay inside the directory.
+type Naming struct {
+	Dir      string // Directory template; "~" is the home directory
+	Filename string // File name template, without an extension
+}
+
+// DefaultNaming returns the naming used when none is configured
+func DefaultNaming() Naming {
+	return Naming{Dir: DefaultDirTemplate, Filename: DefaultFilenameTemplate}
+}
+
+// Validate reports whether both templates can be expanded, using
+// placeholder details for the capture
+func (n Naming) Validate() error {
+	info := CaptureInfo{Time: time.Now(), App: "App", Title: "Title"}
+	if _, err := n.expandDir(info); err != nil {
+		return err
+	}
+	_, err := n.expandFilename(info)
+	return err
+}
+
+// OutputDir returns the expanded directory for a capture, creating it if
+// necessary
+func (n Naming) OutputDir(info CaptureInfo) (string, error) {
+	dir, err := n.expandDir(info)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return dir, nil
+}
+
+// Path returns the path to save a capture to, with the given extension,
+// creating any directories it needs
+func (n Naming) Path(info CaptureInfo, ext string) (string, error) {
+	dir, err := n.OutputDir(info)
+	if err != nil {
+		return "", err
+	}
+	name, err := n.expandFilename(info)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := checkWithin(dir, path); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if strings.Contains(path, seqMarker) {
+		seq, width := nextSequence(path, info.Time)
+		path = strings.Replace(path, seqMarker+strconv.Itoa(width), fmt.Sprintf("%0*d", width, seq), 1)
+	}
+	return path + ext, nil
+}
+
+// expandDir expands the directory template to an absolute path
+func (n Naming) expandDir(info CaptureInfo) (string, error) {
+	tmpl := n.Dir
+	if tmpl == "" {
+		tmpl = DefaultDirTemplate
+	}
+	dir, err := expand(tmpl, info)
+	if err != nil {
+		return "", fmt.Errorf("invalid output directory %q: %w", n.Dir, err)
+	}
+	if strings.Contains(dir, seqMarker) {
+		return "", fmt.Errorf("invalid output directory %q: {seq} can only be used in the file name", n.Dir)
+	}
+
+	// "~" and relative directories are in the home directory
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		dir = strings.TrimPrefix(dir[1:], "/")
+	}
+	if !filepath.IsAbs(dir) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dir = filepath.Join(home, dir)
+	}
+	return filepath.Clean(dir), nil
+}
+
+// expandFilename expands the file name template to a slash-separated path
+// relative to the output directory. A {seq} token is left as seqMarker
+// followed by its width.
+func (n Naming) expandFilename(info CaptureInfo) (string, error) {
+	tmpl := n.Filename
+	if tmpl == "" {
+		tmpl = DefaultFilenameTemplate
+	}
+	if strings.Contains(tmpl, `\`) || strings.HasPrefix(tmpl, "/") {
+		return "", fmt.Errorf("invalid file name %q: path traversal not allowed", n.Filename)
+	}
+
+	name, err := expand(tmpl, info)
+	if err != nil {
+		return "", fmt.Errorf("invalid file name %q: %w", n.Filename, err)
+	}
+
+	segments := strings.Split(name, "/")
+	for i, seg := range segments {
+		if seg == "" {
+			return "", fmt.Errorf("invalid file name %q: expands to an empty name", n.Filename)
+		}
+		if seg == "." || seg == ".." {
+			return "", fmt.Errorf("invalid file name %q: path traversal not allowed", n.Filename)
+		}
+		if i < len(segments)-1 && strings.Contains(seg, seqMarker) {
+			return "", fmt.Errorf("invalid file name %q: {seq} can only be used in the last part", n.Filename)
+		}
+	}
+	if strings.Count(name, seqMarker) > 1 {
+		return "", fmt.Errorf("invalid file name %q: {seq} can only be used once", n.Filename)
+	}
+	return name, nil
+}
+
+// expand replaces the tokens in a template. {seq} becomes seqMarker
+// followed by its width as a single digit.
+func expand(tmpl string, info CaptureInfo) (string, error) {
+	t := info.Time
+	var b strings.Builder
+	for {
+		open := strings.IndexByte(tmpl, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[open:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unclosed {")
+		}
+		b.WriteString(tmpl[:open])
+		token, arg, _ := strings.Cut(tmpl[open+1:open+end], ":")
+		tmpl = tmpl[open+end+1:]
+
+		if token == "seq" {
+			width := 1
+			if arg != "" {
+				w, err := strconv.Atoi(arg)
+				if err != nil || w < 1 || w > 9 {
+					return "", fmt.Errorf("invalid {seq} width %q", arg)
+				}
+				width = w
+			}
+			b.WriteString(seqMarker + strconv.Itoa(width))
+			continue
+		}
+		if arg != "" {
+			return "", fmt.Errorf("{%s} takes no argument", token)
+		}
+
+		var value string
+		switch token {
+		case "date":
+			value = t.Format("2006-01-02")
+		case "time":
+			value = t.Format("150405")
+		case "yyyy":
+			value = t.Format("2006")
+		case "yy":
+			value = t.Format("06")
+		case "MM":
+			value = t.Format("01")
+		case "dd":
+			value = t.Format("02")
+		case "HH":
+			value = t.Format("15")
+		case "mm":
+			value = t.Format("04")
+		case "ss":
+			value = t.Format("05")
+		case "display":
+			value = strconv.Itoa(info.Display + 1)
+		case "title":
+			value = cmp.Or(sanitizeToken(info.Title), unknownToken)
+		case "app":
+			value = cmp.Or(sanitizeToken(strings.TrimSuffix(info.App, ".app")), unknownToken)
+		default:
+			return "", fmt.Errorf("unknown token {%s}", token)
+		}
+		b.WriteString(value)
+	}
+	b.WriteString(tmpl)
+	return b.String(), nil
+}
+
+// sanitizeToken makes a window title or app name safe to use in a file
+// name: path separators and characters that file systems reject become
+// dashes, runs of spaces collapse, and leading or trailing dots and spaces
+// are dropped so the value can't name a parent or hidden directory
+func sanitizeToken(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|{}`, r):
+			r = '-'
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+	}
+
+	out := []rune(strings.Trim(b.String(), ". "))
+	if len(out) > maxTokenLength {
+		out = []rune(strings.TrimRight(string(out[:maxTokenLength]), ". "))
+	}
+	return string(out)
+}
+
+// nextSequence returns the number following the highest one used that day
+// by files matching path, where seqMarker and a width digit stand for the
+// number, along with that width. Files with any extension count.
+func nextSequence(path string, day time.Time) (seq, width int) {
+	dir, name := filepath.Split(path)
+	before, after, _ := strings.Cut(name, seqMarker)
+	width = int(after[0] - '0')
+	after = after[1:]
+
+	pattern := regexp.MustCompile("^" + regexp.QuoteMeta(before) + `(\d+)` + regexp.QuoteMeta(after) + `(\..*)?$`)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 1, width
+	}
+	y, m, d := day.Date()
+	highest := 0
+	for _, entry := range entries {
+		match := pattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if fy, fm, fd := fi.ModTime().In(day.Location()).Date(); fy != y || fm != m || fd != d {
+			continue
+		}
+		if n, err := strconv.Atoi(match[1]); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return highest + 1, width
+}
+
+// checkWithin returns an error unless path lies inside dir
+func checkWithin(dir, path string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve output path: %w", err)
+	}
+	if !strings.HasPrefix(absPath, absDir+string(filepath.Separator)) {
+		return fmt.Errorf("invalid filename: path escapes output directory")
+	}
+	return nil
+}
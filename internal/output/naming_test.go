@@ -0,0 +1,169 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testCapture = CaptureInfo{
+	Time:    time.Date(2024, 1, 15, 14, 30, 52, 0, time.Local),
+	Display: 1,
+	App:     "Safari.app",
+	Title:   "Docs: ../../etc/passwd",
+}
+
+func TestNamingPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		naming Naming
+		want   string
+	}{
+		{DefaultNaming(), "Pictures/schnappit/schnappit-2024-01-15-143052.png"},
+		{Naming{}, "Pictures/schnappit/schnappit-2024-01-15-143052.png"},
+		{Naming{Dir: "~/shots/{yyyy}", Filename: "{MM}/{dd}/{HH}{mm}{ss}-{yy}"}, "shots/2024/01/15/143052-24.png"},
+		{Naming{Dir: "shots", Filename: "{app} display {display}"}, "shots/Safari display 2.png"},
+		{Naming{Dir: "shots", Filename: "{title}"}, "shots/Docs- ..-..-etc-passwd.png"},
+	}
+	for _, tt := range tests {
+		got, err := tt.naming.Path(testCapture, ".png")
+		if err != nil {
+			t.Errorf("%+v: Path() error = %v", tt.naming, err)
+			continue
+		}
+		if want := filepath.Join(home, tt.want); got != want {
+			t.Errorf("%+v: Path() = %q, want %q", tt.naming, got, want)
+		}
+		if _, err := os.Stat(filepath.Dir(got)); err != nil {
+			t.Errorf("%+v: directory not created: %v", tt.naming, err)
+		}
+	}
+}
+
+func TestNamingAppFolders(t *testing.T) {
+	dir := t.TempDir()
+	n := Naming{Dir: dir, Filename: "{app}/{title}-{date}"}
+	if err := n.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	got, err := n.Path(testCapture, ".png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "Safari", "Docs- ..-..-etc-passwd-2024-01-15.png"); got != want {
+		t.Errorf("Path() = %q, want %q", got, want)
+	}
+
+	// Captures without a known app or title still get a folder
+	got, err = n.Path(CaptureInfo{Time: testCapture.Time, Title: ".."}, ".png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "unknown", "unknown-2024-01-15.png"); got != want {
+		t.Errorf("Path() = %q, want %q", got, want)
+	}
+}
+
+func TestNamingAbsoluteDir(t *testing.T) {
+	dir := t.TempDir()
+	got, err := Naming{Dir: dir, Filename: "shot"}.Path(testCapture, ".jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "shot.jpg"); got != want {
+		t.Errorf("Path() = %q, want %q", got, want)
+	}
+}
+
+func TestNamingRejectsTraversal(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"../escape",
+		"a/../../escape",
+		"/etc/passwd",
+		`a\b`,
+		"a//b",
+		"{seq}/shot",
+		"{seq}-{seq}",
+		"{unknown}",
+		"{date",
+		"{seq:x}",
+	} {
+		if _, err := (Naming{Dir: dir, Filename: name}).Path(CaptureInfo{Time: time.Now()}, ".png"); err == nil {
+			t.Errorf("Path() with file name %q should fail", name)
+		}
+	}
+	if err := (Naming{Dir: dir, Filename: "ok"}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (Naming{Dir: dir + "/{seq}", Filename: "ok"}).Validate(); err == nil {
+		t.Error("Validate() should reject {seq} in the directory")
+	}
+}
+
+func TestNamingSequence(t *testing.T) {
+	dir := t.TempDir()
+	naming := Naming{Dir: dir, Filename: "shot-{seq:3}"}
+	today := CaptureInfo{Time: time.Now()}
+
+	next := func() string {
+		t.Helper()
+		path, err := naming.Path(today, ".png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return filepath.Base(path)
+	}
+
+	if got := next(); got != "shot-001.png" {
+		t.Errorf("first = %q, want shot-001.png", got)
+	}
+	if got := next(); got != "shot-002.png" {
+		t.Errorf("second = %q, want shot-002.png", got)
+	}
+
+	// Files from earlier days and other names don't count
+	old := filepath.Join(dir, "shot-007.jpg")
+	if err := os.WriteFile(old, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().AddDate(0, 0, -1)
+	if err := os.Chtimes(old, yesterday, yesterday); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "other-050.png"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := next(); got != "shot-003.png" {
+		t.Errorf("third = %q, want shot-003.png", got)
+	}
+}
+
+func TestSanitizeToken(t *testing.T) {
+	tests := map[string]string{
+		"Safari":                     "Safari",
+		"  My   Window\t":            "My Window",
+		"..":                         "",
+		"a/b\\c:d*e?f\"g<h>i|j":      "a-b-c-d-e-f-g-h-i-j",
+		".hidden.":                   "hidden",
+		strings.Repeat("x", 100):     strings.Repeat("x", maxTokenLength),
+		"line\nbreak\x00":            "line break-",
+		"Ünïcödé — ok":               "Ünïcödé — ok",
+		"{date}":                     "-date-",
+		"dots..../..":                "dots....-",
+		"ends with spaces and dots ": "ends with spaces and dots",
+	}
+	for in, want := range tests {
+		if got := sanitizeToken(in); got != want {
+			t.Errorf("sanitizeToken(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
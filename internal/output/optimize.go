@@ -0,0 +1,335 @@
+package output
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"slices"
+
+	xdraw "golang.org/x/image/draw"
+
+	"github.com/owenrumney/schnappit/internal/project"
+)
+
+// maxQuantizeColors is the most distinct colours an image can have and
+// still be quantised. Screenshots of text and interfaces stay well below
+// it; photographs go over it, and would show the dithering.
+const maxQuantizeColors = 32768
+
+// compressionLevels are the levels OptimizePNG tries. The best level is
+// usually smallest, but not always, so the others are tried too; the first
+// is kept when they tie.
+var compressionLevels = []png.CompressionLevel{png.BestCompression, png.DefaultCompression, png.BestSpeed}
+
+// OptimizeOptions controls how OptimizePNG shrinks an image. Each encoding
+// is always tried at every compression level, images with 256 colours or
+// fewer are always tried with a palette, and opaque images are stored
+// without alpha.
+type OptimizeOptions struct {
+	// Quantize reduces images with more than 256 colours, but still
+	// relatively few, to a 256 colour palette with dithering
+	Quantize bool
+
+	// ScaleFactor is the capture's scale factor. If it is above 1, the image
+	// is downscaled to 1x, its size in logical points.
+	ScaleFactor float64
+
+	// Project, if set, is embedded so the PNG can be opened again with its
+	// annotations editable
+	Project *project.Project
+}
+
+// OptimizeReport describes what OptimizePNG did
+type OptimizeReport struct {
+	Before     int // Size as a plain PNG, in bytes
+	After      int // Size written, in bytes
+	Colors     int // Distinct colours in the image, or 0 if there were too many to count
+	Paletted   bool
+	Quantized  bool
+	Downscaled bool
+}
+
+// String summarises the report, e.g. "1.2 MB → 340.0 KB (72% smaller)"
+func (r OptimizeReport) String() string {
+	s := fmt.Sprintf("%s → %s", formatBytes(r.Before), formatBytes(r.After))
+	switch {
+	case r.Before == 0:
+	case r.After <= r.Before:
+		s += fmt.Sprintf(" (%.0f%% smaller)", 100*(1-float64(r.After)/float64(r.Before)))
+	default:
+		s += fmt.Sprintf(" (%.0f%% larger)", 100*(float64(r.After)/float64(r.Before)-1))
+	}
+	return s
+}
+
+// encodeSmallest encodes img as a PNG at each compression level and
+// returns the smallest result
+func encodeSmallest(img image.Image) (*bytes.Buffer, error) {
+	var best *bytes.Buffer
+	for _, level := range compressionLevels {
+		var buf bytes.Buffer
+		enc := png.Encoder{CompressionLevel: level}
+		if err := enc.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+		if best == nil || buf.Len() < best.Len() {
+			best = &buf
+		}
+	}
+	return best, nil
+}
+
+// formatBytes formats a size in bytes for people, e.g. "340.0 KB"
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// OptimizePNG writes img to w as a PNG made as small as the options allow,
+// and reports the size before and after
+func OptimizePNG(w io.Writer, img image.Image, opts OptimizeOptions) (OptimizeReport, error) {
+	var report OptimizeReport
+
+	var plain bytes.Buffer
+	if err := png.Encode(&plain, img); err != nil {
+		return report, fmt.Errorf("failed to encode image: %w", err)
+	}
+	report.Before = plain.Len()
+
+	// Images are drawn into an *image.RGBA, which the encoder writes as
+	// 8-bit truecolour, without the alpha channel when it is fully opaque.
+	// Other types don't all get that: 16-bit and custom colour models are
+	// written at 16 bits a channel, with alpha unless they report being
+	// opaque.
+	rgba := toRGBA(img)
+	if opts.ScaleFactor > 1 {
+		b := rgba.Bounds()
+		size := image.Pt(max(1, int(math.Round(float64(b.Dx())/opts.ScaleFactor))), max(1, int(math.Round(float64(b.Dy())/opts.ScaleFactor))))
+		scaled := image.NewRGBA(image.Rectangle{Max: size})
+		xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), rgba, b, xdraw.Src, nil)
+		rgba = scaled
+		report.Downscaled = true
+	}
+
+	best, err := encodeSmallest(rgba)
+	if err != nil {
+		return report, err
+	}
+
+	// Try a palette too, keeping it only if it comes out smaller
+	hist := histogram(rgba, maxQuantizeColors)
+	report.Colors = len(hist)
+	if hist != nil && (len(hist) <= 256 || opts.Quantize) {
+		palette := medianCut(hist, 256)
+		var paletted *image.Paletted
+		if len(hist) <= 256 {
+			paletted = exactPaletted(rgba, palette)
+		} else {
+			paletted = image.NewPaletted(rgba.Bounds(), palette)
+			draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), rgba, rgba.Bounds().Min)
+		}
+
+		buf, err := encodeSmallest(paletted)
+		if err != nil {
+			return report, err
+		}
+		if buf.Len() < best.Len() {
+			best = buf
+			report.Paletted = true
+			report.Quantized = len(hist) > 256
+		}
+	}
+
+	data := best.Bytes()
+	if opts.Project != nil {
+		if data, err = EmbedProject(data, opts.Project); err != nil {
+			return report, err
+		}
+	}
+	if _, err := w.Write(data); err != nil {
+		return report, err
+	}
+	report.After = len(data)
+	return report, nil
+}
+
+// toRGBA returns img as an *image.RGBA, copying it if necessary
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(b)
+	draw.Draw(rgba, b, img, b.Min, draw.Src)
+	return rgba
+}
+
+// exactPaletted converts img to a paletted image using a palette that holds
+// every one of its colours. Looking each colour up directly is much faster
+// than the nearest colour search image/draw would do.
+func exactPaletted(img *image.RGBA, palette color.Palette) *image.Paletted {
+	index := make(map[color.RGBA]uint8, len(palette))
+	for i, c := range palette {
+		index[c.(color.RGBA)] = uint8(i)
+	}
+	b := img.Bounds()
+	paletted := image.NewPaletted(b, palette)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			paletted.SetColorIndex(x, y, index[img.RGBAAt(x, y)])
+		}
+	}
+	return paletted
+}
+
+// histogram counts the pixels of each colour in img, or returns nil if
+// there are more than limit distinct colours
+func histogram(img *image.RGBA, limit int) map[color.RGBA]int {
+	hist := make(map[color.RGBA]int)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			hist[img.RGBAAt(x, y)]++
+			if len(hist) > limit {
+				return nil
+			}
+		}
+	}
+	return hist
+}
+
+// colorBox is a set of colours that median cut splits further
+type colorBox struct {
+	colors []color.RGBA
+	counts []int
+}
+
+// packRGBA packs a colour into one number, for sorting
+func packRGBA(c color.RGBA) int64 {
+	return int64(c.R)<<24 | int64(c.G)<<16 | int64(c.B)<<8 | int64(c.A)
+}
+
+// channel returns channel i of c: red, green, blue or alpha
+func channel(c color.RGBA, i int) uint8 {
+	return [4]uint8{c.R, c.G, c.B, c.A}[i]
+}
+
+// widest returns the channel with the largest range in the box, and that range
+func (b colorBox) widest() (ch, spread int) {
+	for i := range 4 {
+		lo, hi := uint8(255), uint8(0)
+		for _, c := range b.colors {
+			v := channel(c, i)
+			lo, hi = min(lo, v), max(hi, v)
+		}
+		if int(hi)-int(lo) > spread {
+			ch, spread = i, int(hi)-int(lo)
+		}
+	}
+	return ch, spread
+}
+
+// mean returns the average colour of the box, weighted by pixel count
+func (b colorBox) mean() color.Color {
+	var sum [4]int
+	total := 0
+	for i, c := range b.colors {
+		for ch := range 4 {
+			sum[ch] += int(channel(c, ch)) * b.counts[i]
+		}
+		total += b.counts[i]
+	}
+	return color.RGBA{uint8(sum[0] / total), uint8(sum[1] / total), uint8(sum[2] / total), uint8(sum[3] / total)}
+}
+
+// medianCut chooses a palette of at most n colours for the histogram. With
+// n or fewer colours the palette is exact; otherwise the colours are split
+// repeatedly along their widest channel at the median pixel, and each
+// group is replaced by its average.
+func medianCut(hist map[color.RGBA]int, n int) color.Palette {
+	// Sorted so the same image always gets the same palette
+	all := colorBox{}
+	for c := range hist {
+		all.colors = append(all.colors, c)
+	}
+	slices.SortFunc(all.colors, func(a, b color.RGBA) int {
+		return cmp.Compare(packRGBA(a), packRGBA(b))
+	})
+	for _, c := range all.colors {
+		all.counts = append(all.counts, hist[c])
+	}
+	if len(all.colors) <= n {
+		palette := make(color.Palette, len(all.colors))
+		for i, c := range all.colors {
+			palette[i] = c
+		}
+		return palette
+	}
+
+	boxes := []colorBox{all}
+	for len(boxes) < n {
+		// Split the box with the widest spread of colours
+		split, splitCh, splitSpread := -1, 0, 0
+		for i, box := range boxes {
+			if len(box.colors) < 2 {
+				continue
+			}
+			if ch, spread := box.widest(); spread > splitSpread {
+				split, splitCh, splitSpread = i, ch, spread
+			}
+		}
+		if split < 0 {
+			break
+		}
+
+		box := boxes[split]
+		order := make([]int, len(box.colors))
+		total := 0
+		for i := range order {
+			order[i] = i
+			total += box.counts[i]
+		}
+		slices.SortFunc(order, func(a, b int) int {
+			return int(channel(box.colors[a], splitCh)) - int(channel(box.colors[b], splitCh))
+		})
+
+		// Cut at the median pixel, leaving at least one colour on each side
+		cut, seen := 1, 0
+		for i, idx := range order[:len(order)-1] {
+			seen += box.counts[idx]
+			cut = i + 1
+			if seen*2 >= total {
+				break
+			}
+		}
+
+		var lo, hi colorBox
+		for i, idx := range order {
+			half := &lo
+			if i >= cut {
+				half = &hi
+			}
+			half.colors = append(half.colors, box.colors[idx])
+			half.counts = append(half.counts, box.counts[idx])
+		}
+		boxes[split] = lo
+		boxes = append(boxes, hi)
+	}
+
+	palette := make(color.Palette, len(boxes))
+	for i, box := range boxes {
+		palette[i] = box.mean()
+	}
+	return palette
+}
@@ -0,0 +1,228 @@
+package output
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// PNG colour types, from the IHDR chunk
+const (
+	pngTruecolor      = 2
+	pngPaletted       = 3
+	pngTruecolorAlpha = 6
+)
+
+// pngColorType returns the colour type of an encoded PNG
+func pngColorType(data []byte) byte {
+	return data[25]
+}
+
+// gradient returns an opaque image with about w*h distinct colours
+func gradient(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: 255})
+		}
+	}
+	return img
+}
+
+// noise returns an opaque image of random pixels drawn from about 1000
+// colours, which compresses poorly without a palette
+func noise(w, h int) *image.RGBA {
+	rng := rand.New(rand.NewPCG(1, 2))
+	colors := make([]color.RGBA, 1000)
+	for i := range colors {
+		colors[i] = color.RGBA{uint8(rng.IntN(256)), uint8(rng.IntN(256)), uint8(rng.IntN(256)), 255}
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.SetRGBA(x, y, colors[rng.IntN(len(colors))])
+		}
+	}
+	return img
+}
+
+// blocks returns an opaque image made of a few solid colours
+func blocks() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 120, 80))
+	colors := []color.RGBA{{255, 255, 255, 255}, {30, 30, 30, 255}, {0, 120, 215, 255}, {255, 0, 0, 255}}
+	for y := range 80 {
+		for x := range 120 {
+			img.SetRGBA(x, y, colors[(x/20+y/20)%len(colors)])
+		}
+	}
+	return img
+}
+
+func optimize(t *testing.T, img image.Image, opts OptimizeOptions) ([]byte, OptimizeReport, image.Image) {
+	t.Helper()
+	var buf bytes.Buffer
+	report, err := OptimizePNG(&buf, img, opts)
+	if err != nil {
+		t.Fatalf("OptimizePNG() error = %v", err)
+	}
+	if report.After != buf.Len() {
+		t.Errorf("report.After = %d, but %d bytes were written", report.After, buf.Len())
+	}
+	decoded, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("decoding optimised PNG: %v", err)
+	}
+	return buf.Bytes(), report, decoded
+}
+
+// samePixels reports whether two images have identical pixels
+func samePixels(a, b image.Image) bool {
+	if a.Bounds() != b.Bounds() {
+		return false
+	}
+	for y := a.Bounds().Min.Y; y < a.Bounds().Max.Y; y++ {
+		for x := a.Bounds().Min.X; x < a.Bounds().Max.X; x++ {
+			if color.NRGBAModel.Convert(a.At(x, y)) != color.NRGBAModel.Convert(b.At(x, y)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestOptimizeFewColoursUsesExactPalette(t *testing.T) {
+	img := blocks()
+	data, report, decoded := optimize(t, img, OptimizeOptions{})
+
+	if pngColorType(data) != pngPaletted || !report.Paletted || report.Quantized {
+		t.Errorf("colour type %d, report %+v; want an exact palette", pngColorType(data), report)
+	}
+	if report.Colors != 4 || report.After >= report.Before {
+		t.Errorf("report = %+v, want 4 colours and a smaller file", report)
+	}
+	if !samePixels(img, decoded) {
+		t.Error("palette changed the pixels")
+	}
+}
+
+func TestOptimizeStripsAlphaWhenOpaque(t *testing.T) {
+	img := gradient(256, 256)
+	data, report, decoded := optimize(t, img, OptimizeOptions{})
+	if pngColorType(data) != pngTruecolor || report.Paletted {
+		t.Errorf("colour type %d, report %+v; want lossless RGB", pngColorType(data), report)
+	}
+	if !samePixels(img, decoded) {
+		t.Error("pixels changed without quantising")
+	}
+
+	// Transparency is kept
+	data, _, decoded = optimize(t, testImage(), OptimizeOptions{})
+	if pngColorType(data) != pngTruecolorAlpha {
+		t.Errorf("colour type %d for a transparent image, want RGBA", pngColorType(data))
+	}
+	if !samePixels(testImage(), decoded) {
+		t.Error("pixels of the transparent image changed")
+	}
+}
+
+func TestOptimizeStripsAlphaFromOtherTypes(t *testing.T) {
+	src := gradient(256, 256)
+	nrgba := image.NewNRGBA(src.Bounds())
+	draw.Draw(nrgba, nrgba.Bounds(), src, image.Point{}, draw.Src)
+	nrgba64 := image.NewNRGBA64(src.Bounds())
+	draw.Draw(nrgba64, nrgba64.Bounds(), src, image.Point{}, draw.Src)
+
+	for name, img := range map[string]image.Image{"NRGBA": nrgba, "NRGBA64": nrgba64} {
+		t.Run(name, func(t *testing.T) {
+			data, _, decoded := optimize(t, img, OptimizeOptions{})
+			// The bit depth follows the colour type's position in IHDR
+			if pngColorType(data) != pngTruecolor || data[24] != 8 {
+				t.Errorf("colour type %d at depth %d, want 8-bit RGB", pngColorType(data), data[24])
+			}
+			if !samePixels(src, decoded) {
+				t.Error("pixels changed without quantising")
+			}
+		})
+	}
+}
+
+func TestOptimizeQuantize(t *testing.T) {
+	img := noise(64, 64)
+	data, report, decoded := optimize(t, img, OptimizeOptions{Quantize: true})
+	if pngColorType(data) != pngPaletted || !report.Quantized {
+		t.Fatalf("colour type %d, report %+v; want a quantised palette", pngColorType(data), report)
+	}
+	if p, ok := decoded.(*image.Paletted); !ok || len(p.Palette) > 256 {
+		t.Errorf("decoded %T, want a palette of at most 256 colours", decoded)
+	}
+
+	if report.After >= report.Before {
+		t.Errorf("report = %v, want a smaller file", report)
+	}
+
+	// Without Quantize the same image is kept exactly
+	_, report, decoded = optimize(t, img, OptimizeOptions{})
+	if report.Paletted || !samePixels(img, decoded) {
+		t.Errorf("report = %+v, want lossless output without Quantize", report)
+	}
+}
+
+func TestOptimizeDownscale(t *testing.T) {
+	_, report, decoded := optimize(t, blocks(), OptimizeOptions{ScaleFactor: 2})
+	if !report.Downscaled || decoded.Bounds().Size() != image.Pt(60, 40) {
+		t.Errorf("downscaled to %v, report %+v; want 60x40", decoded.Bounds().Size(), report)
+	}
+	_, report, decoded = optimize(t, blocks(), OptimizeOptions{ScaleFactor: 1})
+	if report.Downscaled || decoded.Bounds().Size() != image.Pt(120, 80) {
+		t.Errorf("at 1x got %v, report %+v; want it unchanged", decoded.Bounds().Size(), report)
+	}
+}
+
+func TestEncodeSmallest(t *testing.T) {
+	for _, img := range []image.Image{gradient(128, 128), noise(64, 64), blocks()} {
+		best, err := encodeSmallest(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, level := range compressionLevels {
+			var buf bytes.Buffer
+			enc := png.Encoder{CompressionLevel: level}
+			if err := enc.Encode(&buf, img); err != nil {
+				t.Fatal(err)
+			}
+			if buf.Len() < best.Len() {
+				t.Errorf("level %d gives %d bytes, smaller than the %d chosen", level, buf.Len(), best.Len())
+			}
+		}
+	}
+}
+
+func TestMedianCut(t *testing.T) {
+	hist := histogram(gradient(64, 64), maxQuantizeColors)
+	palette := medianCut(hist, 16)
+	if len(palette) != 16 {
+		t.Errorf("medianCut() gave %d colours, want 16", len(palette))
+	}
+	if again := medianCut(hist, 16); !slices.Equal(palette, again) {
+		t.Error("medianCut() isn't deterministic")
+	}
+
+	if got := len(medianCut(histogram(blocks(), maxQuantizeColors), 256)); got != 4 {
+		t.Errorf("medianCut() of 4 colours gave %d", got)
+	}
+	if histogram(gradient(64, 64), 100) != nil {
+		t.Error("histogram() should give up past the limit")
+	}
+}
+
+func TestOptimizeReportString(t *testing.T) {
+	r := OptimizeReport{Before: 2 << 20, After: 512 << 10}
+	if got, want := r.String(), "2.0 MB → 512.0 KB (75% smaller)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
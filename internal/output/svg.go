@@ -0,0 +1,103 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+)
+
+// EncodeSVG writes an SVG document with the screenshot embedded as a base64
+// PNG <image> and each annotation as native SVG elements, so callouts stay
+// crisp at any zoom. If frame isn't nil the document is framed as Beautify
+// would frame it: the background and shadow are embedded as a PNG behind
+// the screenshot, which is clipped to the frame's rounded corners along
+// with the annotations.
+func EncodeSVG(w io.Writer, screenshot image.Image, annotations []tools.Annotation, frame *Frame) error {
+	screenshotURI, err := pngDataURI(screenshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode screenshot: %w", err)
+	}
+
+	b := screenshot.Bounds()
+	view := b
+	if frame != nil {
+		view = b.Inset(-max(0, frame.Padding))
+	}
+
+	bw := bufio.NewWriter(w)
+	// The view box extends from the screenshot's bounds so annotations can
+	// use image coordinates directly
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="%d %d %d %d">`+"\n",
+		view.Dx(), view.Dy(), view.Min.X, view.Min.Y, view.Dx(), view.Dy())
+
+	if frame != nil {
+		// Framing a transparent stand-in leaves just the background and
+		// shadow for the screenshot to be drawn over
+		backdropURI, err := pngDataURI(Beautify(image.NewRGBA(b), *frame))
+		if err != nil {
+			return fmt.Errorf("failed to encode frame: %w", err)
+		}
+		radius := min(max(0, frame.CornerRadius), b.Dx()/2, b.Dy()/2)
+		fmt.Fprintf(bw, `<image x="%d" y="%d" width="%d" height="%d" href="%s"/>`+"\n",
+			view.Min.X, view.Min.Y, view.Dx(), view.Dy(), backdropURI)
+		fmt.Fprintf(bw, `<defs><clipPath id="frame-clip"><rect x="%d" y="%d" width="%d" height="%d" rx="%d" ry="%d"/></clipPath></defs>`+"\n",
+			b.Min.X, b.Min.Y, b.Dx(), b.Dy(), radius, radius)
+		bw.WriteString(`<g clip-path="url(#frame-clip)">` + "\n")
+	}
+
+	fmt.Fprintf(bw, `<image id="%s" x="%d" y="%d" width="%d" height="%d" href="%s"/>`+"\n",
+		tools.ScreenshotID, b.Min.X, b.Min.Y, b.Dx(), b.Dy(), screenshotURI)
+
+	for i, ann := range tools.DrawOrder(annotations) {
+		if tools.IsHidden(ann) {
+			continue
+		}
+		if svg := ann.SVG(fmt.Sprintf("annotation-%d", i)); svg != "" {
+			bw.WriteString(svg + "\n")
+		}
+	}
+	if frame != nil {
+		bw.WriteString("</g>\n")
+	}
+	bw.WriteString("</svg>\n")
+
+	return bw.Flush()
+}
+
+// pngDataURI returns img as a base64 PNG data URI
+func pngDataURI(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// SaveSVGToPath saves the screenshot and annotations as an SVG file, framed
+// if frame isn't nil
+func SaveSVGToPath(screenshot image.Image, annotations []tools.Annotation, frame *Frame, path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, FilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	if err := EncodeSVG(file, screenshot, annotations, frame); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package output
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+)
+
+// svgDocument is the part of an exported SVG the tests inspect
+type svgDocument struct {
+	Width  string `xml:"width,attr"`
+	Height string `xml:"height,attr"`
+	Image  struct {
+		ID   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"image"`
+	Lines    []struct{} `xml:"line"`
+	Rects    []struct{} `xml:"rect"`
+	Groups   []struct{} `xml:"g"`
+	Ellipses []struct{} `xml:"ellipse"`
+}
+
+func TestEncodeSVG(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	img.Set(3, 4, color.RGBA{R: 200, A: 255})
+
+	red := color.NRGBA{R: 255, A: 255}
+	annotations := []tools.Annotation{
+		tools.NewArrow(image.Pt(5, 5), image.Pt(50, 40), red, 2),
+		tools.NewRect(image.Rect(10, 10, 30, 30), red, 2, false),
+		tools.NewEllipse(image.Rect(20, 20, 40, 40), red, 2, false),
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeSVG(&buf, img, annotations, nil); err != nil {
+		t.Fatalf("EncodeSVG() error = %v", err)
+	}
+
+	var doc svgDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("EncodeSVG() produced invalid XML: %v\n%s", err, buf.String())
+	}
+	if doc.Width != "64" || doc.Height != "48" {
+		t.Errorf("Size = %sx%s, want 64x48", doc.Width, doc.Height)
+	}
+	if doc.Image.ID != tools.ScreenshotID {
+		t.Errorf("Image id = %q, want %q", doc.Image.ID, tools.ScreenshotID)
+	}
+	if len(doc.Groups) != 1 || len(doc.Rects) != 1 || len(doc.Ellipses) != 1 {
+		t.Errorf("Got %d groups, %d rects, %d ellipses, want one of each", len(doc.Groups), len(doc.Rects), len(doc.Ellipses))
+	}
+
+	// The embedded screenshot decodes back to the original pixels
+	data, ok := strings.CutPrefix(doc.Image.Href, "data:image/png;base64,")
+	if !ok {
+		t.Fatalf("Image href = %.40q..., want a base64 PNG data URI", doc.Image.Href)
+	}
+	pngData, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		t.Fatalf("Failed to decode base64: %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("Failed to decode embedded PNG: %v", err)
+	}
+	if r, _, _, _ := decoded.At(3, 4).RGBA(); r>>8 != 200 {
+		t.Errorf("Embedded pixel red = %d, want 200", r>>8)
+	}
+}
+
+func TestEncodeSVGDrawsSpotlightFirst(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	annotations := []tools.Annotation{
+		tools.NewLine(image.Pt(0, 0), image.Pt(10, 10), color.Black, 1),
+		tools.NewSpotlight(color.Black, 0.5, false, tools.SpotlightRegion{Rect: image.Rect(0, 0, 5, 5)}),
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeSVG(&buf, img, annotations, nil); err != nil {
+		t.Fatalf("EncodeSVG() error = %v", err)
+	}
+	out := buf.String()
+	if strings.Index(out, "<mask") > strings.Index(out, "<line") {
+		t.Error("EncodeSVG() should emit the spotlight before other annotations")
+	}
+}
+
+func TestEncodeSVGSkipsHidden(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	line := tools.NewLine(image.Pt(0, 0), image.Pt(10, 10), color.Black, 1)
+	tools.SetHidden(line, true)
+
+	var buf bytes.Buffer
+	if err := EncodeSVG(&buf, img, []tools.Annotation{line}, nil); err != nil {
+		t.Fatalf("EncodeSVG() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "<line") {
+		t.Error("EncodeSVG() should leave out hidden annotations")
+	}
+}
+
+func TestEncodeSVGFramed(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	line := tools.NewLine(image.Pt(0, 0), image.Pt(10, 10), color.Black, 1)
+	frame := &Frame{Padding: 10, Background: color.White, CornerRadius: 6}
+
+	var buf bytes.Buffer
+	if err := EncodeSVG(&buf, img, []tools.Annotation{line}, frame); err != nil {
+		t.Fatalf("EncodeSVG() error = %v", err)
+	}
+
+	var doc struct {
+		Width   string `xml:"width,attr"`
+		Height  string `xml:"height,attr"`
+		ViewBox string `xml:"viewBox,attr"`
+		Image   struct {
+			Href string `xml:"href,attr"`
+		} `xml:"image"`
+		Group struct {
+			ClipPath string `xml:"clip-path,attr"`
+			Image    struct {
+				ID string `xml:"id,attr"`
+			} `xml:"image"`
+			Lines []struct{} `xml:"line"`
+		} `xml:"g"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("EncodeSVG() produced invalid XML: %v\n%s", err, buf.String())
+	}
+	if doc.Width != "84" || doc.Height != "68" || doc.ViewBox != "-10 -10 84 68" {
+		t.Errorf("Size = %sx%s with view box %q, want 84x68 padded around the image", doc.Width, doc.Height, doc.ViewBox)
+	}
+	if doc.Group.ClipPath == "" || doc.Group.Image.ID != tools.ScreenshotID || len(doc.Group.Lines) != 1 {
+		t.Errorf("screenshot and annotations should be clipped to the frame: %+v", doc.Group)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(doc.Image.Href, "data:image/png;base64,"))
+	if err != nil {
+		t.Fatalf("backdrop isn't base64: %v", err)
+	}
+	backdrop, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("backdrop isn't a PNG: %v", err)
+	}
+	if backdrop.Bounds().Dx() != 84 || backdrop.Bounds().Dy() != 68 {
+		t.Errorf("backdrop is %v, want 84x68", backdrop.Bounds())
+	}
+	if r, g, b, _ := backdrop.At(0, 0).RGBA(); r>>8 != 255 || g>>8 != 255 || b>>8 != 255 {
+		t.Errorf("backdrop corner = %v, want the white background", backdrop.At(0, 0))
+	}
+}
+
+func TestSaveSVGToPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.svg")
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+
+	if err := SaveSVGToPath(img, nil, nil, path); err != nil {
+		t.Fatalf("SaveSVGToPath() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Saved file missing: %v", err)
+	}
+	if info.Mode().Perm() != FilePermissions {
+		t.Errorf("File permissions = %v, want %v", info.Mode().Perm(), os.FileMode(FilePermissions))
+	}
+}
@@ -0,0 +1,278 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// BodyMultipart sends the image as a file field of a multipart form
+	BodyMultipart = "multipart"
+	// BodyRaw sends the encoded image as the whole request body
+	BodyRaw = "raw"
+
+	// defaultUploadField is the multipart field the image is sent in
+	defaultUploadField = "file"
+
+	// uploadTimeout bounds the whole request when no client is given
+	uploadTimeout = 60 * time.Second
+
+	// maxResponseSize caps how much of a response is read
+	maxResponseSize = 1 << 20
+)
+
+// Uploader sends images to an HTTP endpoint, such as an image host or an
+// internal paste service, and returns the link to the uploaded image
+type Uploader struct {
+	URL    string
+	Method string // POST if empty
+
+	// Body is BodyMultipart (the default) or BodyRaw. Multipart uploads send
+	// the image in Field, "file" if empty, along with any extra Fields.
+	Body   string
+	Field  string
+	Fields map[string]string
+
+	// Headers are added to the request. Values can refer to environment
+	// variables as $NAME or ${NAME}, so tokens needn't be stored in the
+	// config file; naming a variable that isn't set is an error. $$ stands
+	// for a literal $.
+	Headers map[string]string
+
+	// ResponseURL is a JSONPath-style expression such as "$.data.link" or
+	// "files[0].url" that picks the link out of a JSON response. If empty,
+	// the whole response body is the link.
+	ResponseURL string
+
+	// Client sends the request, or a client with a timeout if nil
+	Client *http.Client
+}
+
+// Upload encodes the image, sends it, and returns the link from the response
+func (u Uploader) Upload(ctx context.Context, img image.Image, filename string, opts EncodeOptions) (string, error) {
+	if u.URL == "" {
+		return "", fmt.Errorf("no upload URL configured")
+	}
+	if opts.Format == "" {
+		opts.Format = FormatPNG
+	}
+
+	var encoded bytes.Buffer
+	if err := Encode(&encoded, img, opts); err != nil {
+		return "", fmt.Errorf("failed to encode image: %w", err)
+	}
+	contentType := "image/" + string(opts.Format)
+
+	body, bodyType, err := u.body(encoded.Bytes(), filename, contentType)
+	if err != nil {
+		return "", err
+	}
+
+	method := u.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.URL, body)
+	if err != nil {
+		return "", fmt.Errorf("invalid upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", bodyType)
+	for name, value := range u.Headers {
+		expanded, err := expandEnv(value)
+		if err != nil {
+			return "", fmt.Errorf("header %s: %w", name, err)
+		}
+		req.Header.Set(name, expanded)
+	}
+
+	client := u.Client
+	if client == nil {
+		client = &http.Client{Timeout: uploadTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read upload response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("upload failed: %s: %s", resp.Status, truncate(strings.TrimSpace(string(data)), 200))
+	}
+
+	link, err := u.link(data)
+	if err != nil {
+		return "", err
+	}
+	if parsed, err := url.Parse(link); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", fmt.Errorf("upload response gave %q, which isn't a web link", truncate(link, 200))
+	}
+	return link, nil
+}
+
+// body builds the request body and its content type
+func (u Uploader) body(data []byte, filename, contentType string) (io.Reader, string, error) {
+	switch u.Body {
+	case BodyRaw:
+		return bytes.NewReader(data), contentType, nil
+	case "", BodyMultipart:
+	default:
+		return nil, "", fmt.Errorf("unknown upload body %q, want %q or %q", u.Body, BodyMultipart, BodyRaw)
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for name, value := range u.Fields {
+		expanded, err := expandEnv(value)
+		if err != nil {
+			return nil, "", fmt.Errorf("field %s: %w", name, err)
+		}
+		if err := mw.WriteField(name, expanded); err != nil {
+			return nil, "", err
+		}
+	}
+
+	field := u.Field
+	if field == "" {
+		field = defaultUploadField
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(field), escapeQuotes(filename)))
+	header.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, "", err
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, mw.FormDataContentType(), nil
+}
+
+// link extracts the uploaded image's link from the response body
+func (u Uploader) link(data []byte) (string, error) {
+	if u.ResponseURL == "" {
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("upload response isn't JSON: %w", err)
+	}
+	value, err := lookupJSONPath(doc, u.ResponseURL)
+	if err != nil {
+		return "", err
+	}
+	link, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s in the upload response is %v, not a string", u.ResponseURL, value)
+	}
+	return link, nil
+}
+
+// lookupJSONPath follows a JSONPath-style expression through decoded JSON.
+// It understands an optional leading "$", ".name" and ["name"] for object
+// keys, and [n] for array indexes, e.g. "$.data.files[0]['url']".
+func lookupJSONPath(doc any, path string) (any, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	value := doc
+	for rest != "" {
+		var key string
+		index := -1
+		if rest[0] == '[' {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid response path %q: unclosed [", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			if unquoted, err := strconv.Unquote(strings.ReplaceAll(inner, "'", `"`)); err == nil {
+				key = unquoted
+			} else if n, err := strconv.Atoi(inner); err == nil && n >= 0 {
+				index = n
+			} else {
+				return nil, fmt.Errorf("invalid response path %q: bad index %q", path, inner)
+			}
+		} else {
+			// Names follow a dot, or may start the path bare, as in "data.link"
+			rest = strings.TrimPrefix(rest, ".")
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key, rest = rest[:end], rest[end:]
+		}
+
+		if index >= 0 {
+			list, ok := value.([]any)
+			if !ok || index >= len(list) {
+				return nil, fmt.Errorf("%s not found in the upload response", path)
+			}
+			value = list[index]
+			continue
+		}
+		if key == "" {
+			return nil, fmt.Errorf("invalid response path %q: empty name", path)
+		}
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s not found in the upload response", path)
+		}
+		if value, ok = obj[key]; !ok {
+			return nil, fmt.Errorf("%s not found in the upload response", path)
+		}
+	}
+	return value, nil
+}
+
+// expandEnv replaces $NAME and ${NAME} with environment variables, and $$
+// with $, failing if any variables aren't set so a missing secret isn't
+// sent as blank
+func expandEnv(s string) (string, error) {
+	var missing []string
+	out := os.Expand(s, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s isn't set", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+// escapeQuotes escapes a value for a quoted Content-Disposition parameter
+func escapeQuotes(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// truncate shortens s to at most n bytes for error messages
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
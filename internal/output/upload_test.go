@@ -0,0 +1,158 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUploadMultipart(t *testing.T) {
+	t.Setenv("SCHNAPPIT_TEST_TOKEN", "s3cret")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer s3cret" {
+			t.Errorf("Authorization = %q, want the token from the environment", got)
+		}
+		file, header, err := r.FormFile("image")
+		if err != nil {
+			t.Fatalf("FormFile() error = %v", err)
+		}
+		defer file.Close()
+		if header.Filename != "shot.png" || header.Header.Get("Content-Type") != "image/png" {
+			t.Errorf("file part = %q %q", header.Filename, header.Header.Get("Content-Type"))
+		}
+		if _, err := png.Decode(file); err != nil {
+			t.Errorf("uploaded file isn't a PNG: %v", err)
+		}
+		if got := r.FormValue("album"); got != "docs" {
+			t.Errorf("album field = %q, want docs", got)
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{"files": []any{map[string]any{"link": "https://img.example.com/abc.png"}}},
+		})
+	}))
+	defer srv.Close()
+
+	u := Uploader{
+		URL:         srv.URL,
+		Field:       "image",
+		Fields:      map[string]string{"album": "docs"},
+		Headers:     map[string]string{"Authorization": "Bearer ${SCHNAPPIT_TEST_TOKEN}"},
+		ResponseURL: "$.data.files[0]['link']",
+		Client:      srv.Client(),
+	}
+	link, err := u.Upload(context.Background(), testImage(), "shot.png", EncodeOptions{})
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if link != "https://img.example.com/abc.png" {
+		t.Errorf("Upload() = %q", link)
+	}
+}
+
+func TestUploadRaw(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.Header.Get("Content-Type") != "image/jpeg" {
+			t.Errorf("request = %s %q, want PUT image/jpeg", r.Method, r.Header.Get("Content-Type"))
+		}
+		data, _ := io.ReadAll(r.Body)
+		if !strings.HasPrefix(string(data), "\xff\xd8") {
+			t.Error("body isn't a JPEG")
+		}
+		io.WriteString(w, "  https://paste.example.com/x  \n")
+	}))
+	defer srv.Close()
+
+	u := Uploader{URL: srv.URL, Method: http.MethodPut, Body: BodyRaw, Client: srv.Client()}
+	link, err := u.Upload(context.Background(), testImage(), "shot.jpg", EncodeOptions{Format: FormatJPEG})
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if link != "https://paste.example.com/x" {
+		t.Errorf("Upload() = %q, want the trimmed body", link)
+	}
+}
+
+func TestUploadErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/denied":
+			http.Error(w, "bad token", http.StatusUnauthorized)
+		case "/html":
+			io.WriteString(w, "<html>ok</html>")
+		default:
+			io.WriteString(w, `{"data": {"id": 7}}`)
+		}
+	}))
+	defer srv.Close()
+
+	tests := map[string]Uploader{
+		"status":         {URL: srv.URL + "/denied"},
+		"not a link":     {URL: srv.URL + "/html"},
+		"missing path":   {URL: srv.URL, ResponseURL: "data.link"},
+		"not a string":   {URL: srv.URL, ResponseURL: "data.id"},
+		"not JSON":       {URL: srv.URL + "/html", ResponseURL: "data.link"},
+		"missing secret": {URL: srv.URL, Headers: map[string]string{"X-Token": "$SCHNAPPIT_UNSET_TOKEN"}},
+		"unknown body":   {URL: srv.URL, Body: "form"},
+		"no URL":         {},
+	}
+	for name, u := range tests {
+		t.Run(name, func(t *testing.T) {
+			u.Client = srv.Client()
+			if link, err := u.Upload(context.Background(), testImage(), "shot.png", EncodeOptions{}); err == nil {
+				t.Errorf("Upload() = %q, want an error", link)
+			}
+		})
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("SCHNAPPIT_TEST_TOKEN", "s3cret")
+
+	tests := map[string]string{
+		"Bearer $SCHNAPPIT_TEST_TOKEN":   "Bearer s3cret",
+		"Bearer ${SCHNAPPIT_TEST_TOKEN}": "Bearer s3cret",
+		"costs $$5":                      "costs $5",
+		"pa$$word-$SCHNAPPIT_TEST_TOKEN": "pa$word-s3cret",
+		"no variables":                   "no variables",
+	}
+	for in, want := range tests {
+		if got, err := expandEnv(in); err != nil || got != want {
+			t.Errorf("expandEnv(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if got, err := expandEnv("pa$word"); err == nil {
+		t.Errorf("expandEnv() = %q, want an error for the unset variable", got)
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	var doc any
+	json.Unmarshal([]byte(`{"a": {"b c": [1, {"d": "x"}]}, "e": "y"}`), &doc)
+
+	tests := map[string]any{
+		"e":               "y",
+		"$.e":             "y",
+		`$.a["b c"][1].d`: "x",
+		"a['b c'][0]":     float64(1),
+	}
+	for path, want := range tests {
+		if got, err := lookupJSONPath(doc, path); err != nil || got != want {
+			t.Errorf("lookupJSONPath(%q) = %v, %v; want %v", path, got, err, want)
+		}
+	}
+	for _, path := range []string{"a.missing", "a['b c'][5]", "e[0]", "a[", "a..b", "a[x]"} {
+		if got, err := lookupJSONPath(doc, path); err == nil {
+			t.Errorf("lookupJSONPath(%q) = %v, want an error", path, got)
+		}
+	}
+}
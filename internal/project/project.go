@@ -0,0 +1,195 @@
+// Package project reads and writes .schnappit project files, which keep the
+// original screenshot and every annotation so a capture can be re-edited
+// after it has been exported.
+//
+// A project file is a zip archive holding the screenshot as screenshot.png
+// and a project.json manifest with the format version, capture metadata and
+// the annotations as type-tagged JSON.
+package project
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"time"
+
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+)
+
+const (
+	// Extension is the file extension for project files
+	Extension = ".schnappit"
+
+	// Version is the project format version written by this build. Files
+	// with a newer version are rejected rather than partially loaded.
+	Version = 1
+
+	manifestName   = "project.json"
+	screenshotName = "screenshot.png"
+
+	// filePermissions matches the permissions used for exported screenshots
+	filePermissions = 0600
+)
+
+// ErrUnsupportedVersion is returned when a project was written by a newer
+// version of Schnappit
+var ErrUnsupportedVersion = errors.New("unsupported project version")
+
+// Metadata describes the capture a project was made from
+type Metadata struct {
+	CapturedAt  time.Time `json:"captured_at"`
+	ScaleFactor float64   `json:"scale_factor"`
+}
+
+// Project is an editable capture: the original screenshot and its annotations
+type Project struct {
+	Screenshot  *image.RGBA
+	Annotations []tools.Annotation
+	Metadata    Metadata
+}
+
+// manifest is the JSON document stored alongside the screenshot
+type manifest struct {
+	Version     int               `json:"version"`
+	Metadata    Metadata          `json:"metadata"`
+	Annotations []json.RawMessage `json:"annotations"`
+}
+
+// Write encodes the project as a zip archive
+func Write(w io.Writer, p *Project) error {
+	m := manifest{
+		Version:     Version,
+		Metadata:    p.Metadata,
+		Annotations: make([]json.RawMessage, 0, len(p.Annotations)),
+	}
+	for _, ann := range p.Annotations {
+		data, err := tools.MarshalAnnotation(ann)
+		if err != nil {
+			return err
+		}
+		m.Annotations = append(m.Annotations, data)
+	}
+
+	manifestData, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+
+	mw, err := zw.Create(manifestName)
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if _, err := mw.Write(manifestData); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	// PNG is already compressed, so store it as-is
+	sw, err := zw.CreateHeader(&zip.FileHeader{Name: screenshotName, Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("failed to write screenshot: %w", err)
+	}
+	if err := png.Encode(sw, p.Screenshot); err != nil {
+		return fmt.Errorf("failed to encode screenshot: %w", err)
+	}
+
+	return zw.Close()
+}
+
+// Read decodes a project archive
+func Read(r io.ReaderAt, size int64) (*Project, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not a project file: %w", err)
+	}
+
+	manifestData, err := readEntry(zr, manifestName)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(manifestData, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	if m.Version < 1 || m.Version > Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, m.Version)
+	}
+
+	screenshotData, err := readEntry(zr, screenshotName)
+	if err != nil {
+		return nil, err
+	}
+	img, err := png.Decode(bytes.NewReader(screenshotData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+
+	p := &Project{
+		Screenshot:  toRGBA(img),
+		Annotations: make([]tools.Annotation, 0, len(m.Annotations)),
+		Metadata:    m.Metadata,
+	}
+	for i, data := range m.Annotations {
+		ann, err := tools.UnmarshalAnnotation(data)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %d: %w", i, err)
+		}
+		p.Annotations = append(p.Annotations, ann)
+	}
+	return p, nil
+}
+
+// Save writes the project to path
+func Save(p *Project, path string) error {
+	var buf bytes.Buffer
+	if err := Write(&buf, p); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), filePermissions); err != nil {
+		return fmt.Errorf("failed to write project: %w", err)
+	}
+	return nil
+}
+
+// Open reads the project at path
+func Open(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project: %w", err)
+	}
+	return Read(bytes.NewReader(data), int64(len(data)))
+}
+
+// readEntry returns the contents of the named file in the archive
+func readEntry(zr *zip.Reader, name string) ([]byte, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("project is missing %s: %w", name, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// toRGBA returns img as an *image.RGBA with its origin at zero
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
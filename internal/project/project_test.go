@@ -0,0 +1,125 @@
+package project
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+)
+
+func testProject() *Project {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 40; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x * 6), G: uint8(y * 8), B: 90, A: 255})
+		}
+	}
+
+	red := color.NRGBA{R: 255, A: 255}
+	return &Project{
+		Screenshot: img,
+		Annotations: []tools.Annotation{
+			tools.NewArrow(image.Pt(2, 2), image.Pt(30, 20), red, 3),
+			tools.NewRect(image.Rect(5, 5, 25, 25), red, 2, false),
+			tools.NewSpotlight(color.NRGBA{A: 255}, 0.5, false, tools.SpotlightRegion{Rect: image.Rect(0, 0, 10, 10)}),
+		},
+		Metadata: Metadata{
+			CapturedAt:  time.Date(2026, 3, 14, 9, 26, 53, 0, time.UTC),
+			ScaleFactor: 2,
+		},
+	}
+}
+
+func TestWriteAndRead(t *testing.T) {
+	want := testProject()
+
+	var buf bytes.Buffer
+	if err := Write(&buf, want); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	got, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	if !bytes.Equal(got.Screenshot.Pix, want.Screenshot.Pix) || got.Screenshot.Bounds() != want.Screenshot.Bounds() {
+		t.Error("Read() screenshot does not match the original")
+	}
+	if !reflect.DeepEqual(got.Annotations, want.Annotations) {
+		t.Errorf("Read() annotations = %+v, want %+v", got.Annotations, want.Annotations)
+	}
+	if !got.Metadata.CapturedAt.Equal(want.Metadata.CapturedAt) || got.Metadata.ScaleFactor != want.Metadata.ScaleFactor {
+		t.Errorf("Read() metadata = %+v, want %+v", got.Metadata, want.Metadata)
+	}
+}
+
+func TestSaveAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture"+Extension)
+	want := testProject()
+
+	if err := Save(want, path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(got.Annotations) != len(want.Annotations) {
+		t.Errorf("Open() returned %d annotations, want %d", len(got.Annotations), len(want.Annotations))
+	}
+}
+
+// writeArchive builds a zip archive from name/contents pairs
+func writeArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, contents := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(contents))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadRejectsNewerVersion(t *testing.T) {
+	data := writeArchive(t, map[string]string{manifestName: `{"version": 99}`})
+
+	_, err := Read(bytes.NewReader(data), int64(len(data)))
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Read() error = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"not a zip", []byte("hello")},
+		{"missing manifest", writeArchive(t, map[string]string{screenshotName: ""})},
+		{"missing screenshot", writeArchive(t, map[string]string{manifestName: `{"version": 1}`})},
+		{"bad screenshot", writeArchive(t, map[string]string{manifestName: `{"version": 1}`, screenshotName: "not a png"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Read(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Read() expected error")
+			}
+		})
+	}
+}
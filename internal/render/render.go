@@ -0,0 +1,310 @@
+// Package render draws annotation documents onto images without a GUI, so
+// that annotated documentation images can be regenerated in CI. Annotations
+// are built by the same tools the editor uses, and nothing here imports
+// Fyne.
+//
+// A document names a base image and lists its annotations. Drawn tools
+// take the points of the drag that would draw them in the editor:
+//
+//	image: login.png
+//	scale_factor: 2
+//	annotations:
+//	  - tool: arrow
+//	    from: [40, 300]
+//	    to: [220, 180]
+//	  - tool: rectangle
+//	    from: [220, 120]
+//	    to: [480, 200]
+//	    color: "#0078d7"
+//	  - tool: badge
+//	    at: [230, 130]
+//	  - tool: text
+//	    at: [350, 240]
+//	    text: Sign in here
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // Register JPEG decoding for base images
+	_ "image/png"  // Register PNG decoding for base images
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/owenrumney/schnappit/internal/config"
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+)
+
+// defaultTextSize is the logical text height of text and badges
+const defaultTextSize = 14
+
+// Point is an image position, written as [x, y]
+type Point [2]int
+
+func (p Point) pt() image.Point {
+	return image.Pt(p[0], p[1])
+}
+
+// Document is a base image and the annotations to draw on it
+type Document struct {
+	// Image is the path of the base image, relative to the document
+	Image string `json:"image" yaml:"image"`
+
+	// ScaleFactor is the number of image pixels per logical point, which
+	// stroke widths and text sizes are given in. It defaults to 1.
+	ScaleFactor float64 `json:"scale_factor" yaml:"scale_factor"`
+
+	// Color and StrokeWidth are the style of annotations that don't set
+	// their own, defaulting to the editor's
+	Color       string `json:"color" yaml:"color"`
+	StrokeWidth int    `json:"stroke_width" yaml:"stroke_width"`
+
+	Annotations []Item `json:"annotations" yaml:"annotations"`
+
+	// dir is the directory relative image paths are resolved from
+	dir string
+}
+
+// Item is one annotation in a document
+type Item struct {
+	// Tool is the name of a registered editor tool, such as "arrow",
+	// "rectangle" or "spotlight", or "text" or "badge"
+	Tool string `json:"tool" yaml:"tool"`
+
+	From   Point   `json:"from" yaml:"from"`     // Start of the drag
+	To     Point   `json:"to" yaml:"to"`         // End of the drag
+	Points []Point `json:"points" yaml:"points"` // Freehand path for the pen
+
+	At   Point   `json:"at" yaml:"at"`     // Centre of text and badges
+	Text string  `json:"text" yaml:"text"` // Badges are numbered in order if this is empty
+	Size float64 `json:"size" yaml:"size"` // Logical text height
+
+	Color       string `json:"color" yaml:"color"`
+	StrokeWidth int    `json:"stroke_width" yaml:"stroke_width"`
+	Filled      bool   `json:"filled" yaml:"filled"`
+
+	// Options for the highlighter, spotlight, magnifier and measure tools
+	Shape      string   `json:"shape" yaml:"shape"`     // Spotlight: "rounded" or "ellipse"
+	Opacity    *float64 `json:"opacity" yaml:"opacity"` // Highlighter opacity or spotlight dimming, from 0 to 1
+	Desaturate bool     `json:"desaturate" yaml:"desaturate"`
+	Zoom       float64  `json:"zoom" yaml:"zoom"` // Magnifier zoom, from 2 to 4
+	Connector  *bool    `json:"connector" yaml:"connector"`
+	Mode       string   `json:"mode" yaml:"mode"` // Measure: "distance", "dimensions" or "edges"
+}
+
+// Parse decodes a document. YAML documents are recognised by a .yaml or
+// .yml name; anything else is read as JSON. Unknown fields are rejected so
+// that typos don't silently drop annotations.
+func Parse(data []byte, name string) (*Document, error) {
+	doc := &Document{}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(doc); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+	}
+	return doc, nil
+}
+
+// Load reads a document from disk. Its image path is resolved relative to
+// the document.
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	doc, err := Parse(data, path)
+	if err != nil {
+		return nil, err
+	}
+	doc.dir = filepath.Dir(path)
+	return doc, nil
+}
+
+// ImagePath returns the path of the base image
+func (d *Document) ImagePath() string {
+	if d.Image == "" || filepath.IsAbs(d.Image) {
+		return d.Image
+	}
+	return filepath.Join(d.dir, d.Image)
+}
+
+// LoadImage reads the document's base image
+func (d *Document) LoadImage() (*image.RGBA, error) {
+	path := d.ImagePath()
+	if path == "" {
+		return nil, errors.New("document has no image")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	rgba := image.NewRGBA(image.Rectangle{Max: img.Bounds().Size()})
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba, nil
+}
+
+// Build creates the document's annotations for the base image, in draw
+// order
+func (d *Document) Build(base *image.RGBA) ([]tools.Annotation, error) {
+	defaults := config.Default()
+	scale := d.ScaleFactor
+	if scale <= 0 {
+		scale = 1
+	}
+
+	var annotations []tools.Annotation
+	badges := 0
+	for i, item := range d.Annotations {
+		ctx, err := d.toolContext(item, base, annotations, scale, defaults)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %d (%s): %w", i+1, item.Tool, err)
+		}
+
+		size := item.Size
+		if size <= 0 {
+			size = defaultTextSize
+		}
+		textPixels := max(1, int(size*scale))
+
+		var ann tools.Annotation
+		switch item.Tool {
+		case "text":
+			if item.Text == "" {
+				return nil, fmt.Errorf("annotation %d (text): no text given", i+1)
+			}
+			ann = tools.NewText(item.At.pt(), item.Text, ctx.Color, textPixels)
+		case "badge":
+			badges++
+			text := item.Text
+			if text == "" {
+				text = strconv.Itoa(badges)
+			}
+			ann = tools.NewBadge(item.At.pt(), text, ctx.Color, textPixels)
+		default:
+			tool := tools.LookupTool(item.Tool)
+			if tool == nil {
+				return nil, fmt.Errorf("annotation %d: unknown tool %q", i+1, item.Tool)
+			}
+			settings := item.settings()
+			if err := tool.CheckSettings(settings); err != nil {
+				return nil, fmt.Errorf("annotation %d (%s): %w", i+1, item.Tool, err)
+			}
+			ctx.Settings = tool.Settings(settings)
+			ann = tool.Complete(ctx, item.drag())
+		}
+
+		// Tools that extend an existing annotation, like the spotlight,
+		// return nil and have already changed the list
+		if ann != nil {
+			annotations = append(annotations, ann)
+		}
+	}
+	return annotations, nil
+}
+
+// toolContext returns the style an item is drawn with
+func (d *Document) toolContext(item Item, base *image.RGBA, annotations []tools.Annotation, scale float64, defaults *config.Config) (*tools.ToolContext, error) {
+	hex := item.Color
+	if hex == "" {
+		hex = d.Color
+	}
+	if hex == "" {
+		hex = defaults.ToolColor
+	}
+	c, err := tools.ParseHexColor(hex)
+	if err != nil {
+		return nil, err
+	}
+
+	width := item.StrokeWidth
+	if width <= 0 {
+		width = d.StrokeWidth
+	}
+	if width <= 0 {
+		width = defaults.StrokeWidth
+	}
+
+	return &tools.ToolContext{
+		Screenshot:  base,
+		Annotations: annotations,
+		Color:       c,
+		StrokeWidth: max(1, int(float64(width)*scale)),
+		Filled:      item.Filled,
+		ScaleFactor: scale,
+	}, nil
+}
+
+// settings returns the tool options the item sets
+func (item Item) settings() tools.Settings {
+	settings := tools.Settings{}
+	if item.Shape != "" {
+		settings["shape"] = item.Shape
+	}
+	if item.Opacity != nil {
+		settings["opacity"] = *item.Opacity
+	}
+	if item.Desaturate {
+		settings["desaturate"] = true
+	}
+	if item.Zoom != 0 {
+		settings["zoom"] = item.Zoom
+	}
+	if item.Connector != nil {
+		settings["connector"] = *item.Connector
+	}
+	if item.Mode != "" {
+		settings["mode"] = item.Mode
+	}
+	return settings
+}
+
+// drag returns the editor drag that draws the item. A freehand path runs
+// from its first point to its last.
+func (item Item) drag() tools.Drag {
+	d := tools.Drag{Start: item.From.pt(), End: item.To.pt()}
+	if len(item.Points) > 0 {
+		for _, p := range item.Points {
+			d.Path = append(d.Path, p.pt())
+		}
+		d.Start, d.End = d.Path[0], d.Path[len(d.Path)-1]
+	} else {
+		d.Path = []image.Point{d.Start, d.End}
+	}
+	return d
+}
+
+// Render draws the document's annotations over a copy of base
+func Render(base *image.RGBA, doc *Document) (*image.RGBA, []tools.Annotation, error) {
+	annotations, err := doc.Build(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := image.NewRGBA(base.Bounds())
+	draw.Draw(out, out.Bounds(), base, base.Bounds().Min, draw.Src)
+	tools.Render(out, annotations)
+	return out, annotations, nil
+}
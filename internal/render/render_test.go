@@ -0,0 +1,179 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/owenrumney/schnappit/internal/editor/tools"
+)
+
+const yamlDocument = `
+image: base.png
+scale_factor: 2
+color: "#0078d7"
+annotations:
+  - tool: arrow
+    from: [10, 80]
+    to: [90, 20]
+    color: "#ff0000"
+  - tool: rectangle
+    from: [20, 20]
+    to: [60, 50]
+    stroke_width: 1
+  - tool: badge
+    at: [20, 20]
+  - tool: badge
+    at: [60, 50]
+  - tool: text
+    at: [50, 90]
+    text: Sign in
+  - tool: spotlight
+    from: [0, 0]
+    to: [30, 30]
+  - tool: spotlight
+    from: [50, 50]
+    to: [90, 90]
+    shape: ellipse
+`
+
+func testBase() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	return img
+}
+
+func TestParseYAMLAndJSON(t *testing.T) {
+	fromYAML, err := Parse([]byte(yamlDocument), "doc.yaml")
+	if err != nil {
+		t.Fatalf("Parse(yaml) error = %v", err)
+	}
+	fromJSON, err := Parse([]byte(`{"image": "base.png", "annotations": [{"tool": "arrow", "from": [10, 80], "to": [90, 20]}]}`), "doc.json")
+	if err != nil {
+		t.Fatalf("Parse(json) error = %v", err)
+	}
+
+	if fromYAML.Image != "base.png" || fromYAML.ScaleFactor != 2 || len(fromYAML.Annotations) != 7 {
+		t.Errorf("Parse(yaml) = %+v", fromYAML)
+	}
+	if got := fromJSON.Annotations[0]; got.Tool != "arrow" || got.From != (Point{10, 80}) || got.To != (Point{90, 20}) {
+		t.Errorf("Parse(json) annotation = %+v", got)
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	if _, err := Parse([]byte("annotations:\n  - tool: arrow\n    form: [1, 2]\n"), "doc.yml"); err == nil {
+		t.Error("Parse(yaml) should reject a misspelt field")
+	}
+	if _, err := Parse([]byte(`{"annotations": [{"tool": "arrow", "form": [1, 2]}]}`), "doc.json"); err == nil {
+		t.Error("Parse(json) should reject a misspelt field")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	doc, err := Parse([]byte(yamlDocument), "doc.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations, err := doc.Build(testBase())
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	// The second spotlight region joins the first spotlight
+	if len(annotations) != 6 {
+		t.Fatalf("Build() made %d annotations, want 6", len(annotations))
+	}
+
+	arrow := annotations[0].(*tools.ArrowAnnotation)
+	if arrow.StrokeWidth != 6 || tools.ColorOf(arrow) != (color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("arrow style = width %d colour %v, want the default width at 2x and its own colour", arrow.StrokeWidth, tools.ColorOf(arrow))
+	}
+	rect := annotations[1].(*tools.RectAnnotation)
+	if rect.StrokeWidth != 2 || tools.ColorOf(rect) != (color.NRGBA{G: 0x78, B: 0xd7, A: 255}) {
+		t.Errorf("rectangle style = width %d colour %v, want its own width at 2x and the document colour", rect.StrokeWidth, tools.ColorOf(rect))
+	}
+
+	if first, second := annotations[2].(*tools.BadgeAnnotation), annotations[3].(*tools.BadgeAnnotation); first.Text != "1" || second.Text != "2" {
+		t.Errorf("badges numbered %q and %q, want 1 and 2", first.Text, second.Text)
+	}
+	if text := annotations[4].(*tools.TextAnnotation); text.Text != "Sign in" || text.Size != defaultTextSize*2 {
+		t.Errorf("text = %q at size %d", text.Text, text.Size)
+	}
+
+	spotlight := annotations[5].(*tools.SpotlightAnnotation)
+	if len(spotlight.Regions) != 2 || spotlight.Regions[1].Shape != tools.SpotlightEllipse {
+		t.Errorf("spotlight regions = %+v", spotlight.Regions)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown tool":  `{"annotations": [{"tool": "laser"}]}`,
+		"bad colour":    `{"annotations": [{"tool": "arrow", "color": "red"}]}`,
+		"empty text":    `{"annotations": [{"tool": "text", "at": [5, 5]}]}`,
+		"bad shape":     `{"annotations": [{"tool": "spotlight", "shape": "star"}]}`,
+		"bad mode":      `{"annotations": [{"tool": "measure", "mode": "area"}]}`,
+		"bad zoom":      `{"annotations": [{"tool": "magnifier", "zoom": 8}]}`,
+		"wrong option":  `{"annotations": [{"tool": "arrow", "shape": "ellipse"}]}`,
+		"doc bad color": `{"color": "#zzz", "annotations": [{"tool": "arrow"}]}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, err := Parse([]byte(data), "doc.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := doc.Build(testBase()); err == nil {
+				t.Error("Build() should fail")
+			}
+		})
+	}
+}
+
+func TestRenderFromDisk(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "base.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, testBase()); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	docPath := filepath.Join(dir, "doc.yaml")
+	if err := os.WriteFile(docPath, []byte(yamlDocument), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := Load(docPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !strings.HasPrefix(doc.ImagePath(), dir) {
+		t.Errorf("ImagePath() = %q, want it relative to the document in %q", doc.ImagePath(), dir)
+	}
+
+	base, err := doc.LoadImage()
+	if err != nil {
+		t.Fatalf("LoadImage() error = %v", err)
+	}
+	out, _, err := Render(base, doc)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	// The spotlight dims the corner outside its regions, and the base is untouched
+	if got := out.RGBAAt(95, 5); got.R == 255 {
+		t.Errorf("pixel outside the spotlight = %v, want it dimmed", got)
+	}
+	if got := base.RGBAAt(95, 5); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("base pixel = %v, want it unchanged", got)
+	}
+}